@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// requestStats collects what a request cost while it is served, for the access log.
+type requestStats struct {
+	mu          sync.Mutex
+	cacheHits   int
+	cacheMisses int
+	apiDuration time.Duration
+}
+
+type requestStatsKey struct{}
+
+// requestStatsFrom returns the stats attached to ctx, or nil outside a request.
+// The methods of a nil *requestStats do nothing.
+func requestStatsFrom(ctx context.Context) *requestStats {
+	stats, _ := ctx.Value(requestStatsKey{}).(*requestStats)
+	return stats
+}
+
+func (s *requestStats) recordCache(hit bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if hit {
+		s.cacheHits++
+	} else {
+		s.cacheMisses++
+	}
+}
+
+func (s *requestStats) recordAPI(d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiDuration += d
+}
+
+func timedAPICall[T any](stats *requestStats, call func() (T, error)) func() (T, error) {
+	return func() (T, error) {
+		start := time.Now()
+		defer func() { stats.recordAPI(time.Since(start)) }()
+		return call()
+	}
+}
+
+// accessLogMiddleware logs every request through slog, alongside the
+// application's other JSON logs. It expects middleware.RequestID to run first.
+func accessLogMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		start := time.Now()
+		stats := &requestStats{}
+		req := ctx.Request()
+		ctx.SetRequest(req.WithContext(context.WithValue(req.Context(), requestStatsKey{}, stats)))
+		err := next(ctx)
+		if err != nil {
+			ctx.Error(err)
+		}
+
+		cluster := ctx.Param("cluster")
+		if cluster == "" {
+			cluster = ctx.QueryParam("cluster")
+		}
+		namespace := ctx.Param("namespace")
+		if namespace == "" {
+			namespace = ctx.QueryParam("namespace")
+		}
+		stats.mu.Lock()
+		defer stats.mu.Unlock()
+		slog.Info("request",
+			"request_id", ctx.Response().Header().Get(echo.HeaderXRequestID),
+			"method", req.Method,
+			"uri", req.RequestURI,
+			"route", ctx.Path(),
+			"status", ctx.Response().Status,
+			"remote_ip", ctx.RealIP(),
+			"cluster", cluster,
+			"namespace", namespace,
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+			"cache_hits", stats.cacheHits,
+			"cache_misses", stats.cacheMisses,
+			"kubernetes_api_ms", float64(stats.apiDuration.Microseconds())/1000,
+		)
+		return nil
+	}
+}
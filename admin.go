@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// serveAdmin serves the runtime diagnostics on their own listener, so they are
+// never reachable through the badge port: net/http/pprof under /debug/pprof/,
+// expvar at /debug/vars and the cache contents at /debug/cache.
+func serveAdmin(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/cache", handleDebugCache)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+type cacheDump struct {
+	Entries int                  `json:"entries"`
+	Expired int                  `json:"expired"`
+	Keys    map[string]time.Time `json:"keys"`
+}
+
+func dumpCache(c *ttlCache, now time.Time) cacheDump {
+	keys := c.expirations()
+	dump := cacheDump{Entries: len(keys), Keys: keys}
+	for _, expiresAt := range keys {
+		if now.After(expiresAt) {
+			dump.Expired++
+		}
+	}
+	return dump
+}
+
+// handleDebugCache reports the number of objects held by each informer store
+// and the keys of the list and badge caches with their expiry, to tell which
+// of them grows.
+func handleDebugCache(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	stores := map[string]int{}
+	for kind, kindStores := range informerStores {
+		for _, store := range kindStores {
+			stores[kind] += len(store.ListKeys())
+		}
+	}
+	precomputedMu.Lock()
+	refreshed := len(precomputed)
+	precomputedMu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"informers":   stores,
+		"listCache":   dumpCache(listCache, now),
+		"badgeCache":  dumpCache(badgeCache, now),
+		"precomputed": refreshed,
+	})
+}
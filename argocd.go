@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "argocd/applications",
+			enabled: func() bool { return conf.EnableArgoCD },
+			badge:   argoApplicationsBadge,
+			routes: map[string]echo.HandlerFunc{
+				"/argocd/applications/:name": handleArgoApplication,
+			},
+		},
+		count: countArgoApplications,
+	})
+}
+
+var argoApplicationGVR = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "applications"}
+
+func argoStatus(app *unstructured.Unstructured) (sync, health string) {
+	sync, _, _ = unstructured.NestedString(app.Object, "status", "sync", "status")
+	health, _, _ = unstructured.NestedString(app.Object, "status", "health", "status")
+	return sync, health
+}
+
+// argoColor is red when any app is Degraded, yellow when any is OutOfSync and
+// otherwise follows the healthy ratio.
+func argoColor(count healthCount, degraded, outOfSync int, params url.Values) string {
+	switch {
+	case degraded > 0:
+		return BADGE_COLOR_FATAL
+	case outOfSync > 0:
+		return BADGE_COLOR_WARN
+	default:
+		return count.color(params)
+	}
+}
+
+func evaluateArgoApplications(ctx context.Context, params url.Values) (count healthCount, degraded, outOfSync int, err error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, 0, 0, err
+	}
+	apps, err := listCustom(ctx, argoApplicationGVR, q)
+	if err != nil {
+		return healthCount{}, 0, 0, err
+	}
+	annotation := params.Get("annotation")
+	for _, app := range apps {
+		if !matchAnnotation(app, annotation) {
+			continue
+		}
+		sync, health := argoStatus(app)
+		count.add(app, sync == "Synced" && health == "Healthy")
+		if health == "Degraded" {
+			degraded++
+		}
+		if sync == "OutOfSync" {
+			outOfSync++
+		}
+	}
+	return count, degraded, outOfSync, nil
+}
+
+func countArgoApplications(ctx context.Context, params url.Values) (healthCount, error) {
+	count, _, _, err := evaluateArgoApplications(ctx, params)
+	return count, err
+}
+
+func argoApplicationsBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, degraded, outOfSync, err := evaluateArgoApplications(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := countBadge(badgeLabel("argocd", params), count, params)
+	b.Color = argoColor(count, degraded, outOfSync, params)
+	return b, nil
+}
+
+// argoApplicationBadge reports a single Application looked up in ?namespace=
+// (APP_ARGOCD_NAMESPACE by default).
+func argoApplicationBadge(name string) badgeFunc {
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		q, err := newListQuery(ctx, params)
+		if err != nil {
+			return badge{}, err
+		}
+		// q.Namespaces falls back to APP_NAMESPACES, so only an explicit
+		// ?namespace=, which newListQuery checked against them, overrides
+		// APP_ARGOCD_NAMESPACE.
+		namespace := conf.ArgoCDNamespace
+		if len(splitList(params.Get("namespace"))) > 0 {
+			namespace = q.Namespaces[0]
+		}
+		getCtx, cancel := withK8sTimeout(ctx)
+		defer cancel()
+		app, err := q.dynamicClient().Resource(argoApplicationGVR).Namespace(namespace).Get(getCtx, name, v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return badge{}, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("application %s/%s not found", namespace, name))
+		}
+		recordClusterCall(q.Cluster, err)
+		if err != nil {
+			kubernetesErrorsTotal.WithLabelValues(argoApplicationGVR.String(), q.Cluster).Inc()
+			return badge{}, err
+		}
+		sync, health := argoStatus(app)
+		count := healthCount{Total: 1}
+		color := BADGE_COLOR_WARN
+		switch {
+		case health == "Degraded":
+			color = BADGE_COLOR_FATAL
+		case sync == "Synced" && health == "Healthy":
+			count.Healthy = 1
+			color = BADGE_COLOR_HEALTHY
+		}
+		return badge{
+			Label:   name,
+			Message: fmt.Sprintf("%s, %s", sync, health),
+			Color:   color,
+			Count:   count,
+		}, nil
+	}
+}
+
+func handleArgoApplication(ctx echo.Context) error {
+	name := ctx.Param("name")
+	return serveBadge(ctx, "argocd/applications/"+name, ctx.QueryParams(), argoApplicationBadge(name))
+}
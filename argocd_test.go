@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func testApplication(namespace, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]any{"name": name, "namespace": namespace},
+		"status": map[string]any{
+			"sync":   map[string]any{"status": "Synced"},
+			"health": map[string]any{"status": "Healthy"},
+		},
+	}}
+}
+
+func TestArgoApplicationNamespace(t *testing.T) {
+	setupTest(t, map[string]string{"APP_ENABLE_ARGOCD": "true", "APP_NAMESPACES": "default,apps"})
+	dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{argoApplicationGVR: "ApplicationList"},
+		testApplication("argocd", "web"), testApplication("apps", "api"),
+	)
+	for _, tt := range []struct{ path, want string }{
+		// APP_NAMESPACES must not replace APP_ARGOCD_NAMESPACE by default.
+		{path: "/argocd/applications/web", want: "Synced, Healthy"},
+		{path: "/argocd/applications/api?namespace=apps", want: "Synced, Healthy"},
+		{path: "/argocd/applications/web?namespace=argocd", want: "namespace not allowed: argocd"},
+	} {
+		if got := getMessage(t, tt.path); got != tt.want {
+			t.Errorf("%s: message = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// authExemptPaths are served without a token so probes keep working.
+var authExemptPaths = map[string]bool{
+	"/livez":       true,
+	"/healthz":     true,
+	"/readyz":      true,
+	"/favicon.ico": true,
+}
+
+// authMiddleware requires one of tokens via ?token= or an Authorization: Bearer
+// header. Signed badge URLs are accepted instead when APP_SIGNING_SECRET is set,
+// as are POST /batch requests, whose items handleBatch checks one by one.
+func authMiddleware(tokens []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if authExemptPaths[ctx.Path()] || validToken(requestToken(ctx), tokens) {
+				return next(ctx)
+			}
+			if conf.SigningSecret != "" && ctx.Path() == "/batch" {
+				return next(ctx)
+			}
+			if conf.SigningSecret != "" && ctx.QueryParam("sig") != "" && checkSignature(ctx, conf.SigningSecret) == nil {
+				return next(ctx)
+			}
+			return respondError(ctx, echo.NewHTTPError(http.StatusUnauthorized, "unauthorized"))
+		}
+	}
+}
+
+// tokenRequired guards the endpoints that change what every badge shows: they
+// need one of APP_AUTH_TOKENS, and are refused while none is configured.
+func tokenRequired(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		if !validToken(requestToken(ctx), conf.AuthTokens) {
+			return respondError(ctx, echo.NewHTTPError(http.StatusUnauthorized, "unauthorized"))
+		}
+		return next(ctx)
+	}
+}
+
+func requestToken(ctx echo.Context) string {
+	if token := ctx.QueryParam("token"); token != "" {
+		return token
+	}
+	header := ctx.Request().Header.Get(echo.HeaderAuthorization)
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+func validToken(token string, tokens []string) bool {
+	if token == "" {
+		return false
+	}
+	valid := false
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	BADGE_COLOR_FATAL   = "red"
+	BADGE_COLOR_WARN    = "yellow"
+	BADGE_COLOR_HEALTHY = "blue"
+	BADGE_COLOR_ERROR   = "lightgrey"
+)
+
+type healthCount struct {
+	Healthy int
+	Total   int
+	// Critical counts the unhealthy objects matching a critical rule.
+	Critical int
+	// Items lists the objects behind the counts for ?format=json.
+	Items []itemStatus
+	// Groups breaks the unhealthy objects down by cause for ?format=json, e.g.
+	// by registry host for imagepull.
+	Groups map[string]int
+}
+
+type itemStatus struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Healthy   bool   `json:"healthy"`
+	Critical  bool   `json:"critical,omitempty"`
+}
+
+func newItemStatus(obj v1.Object, healthy bool) itemStatus {
+	return itemStatus{Namespace: obj.GetNamespace(), Name: obj.GetName(), Healthy: healthy}
+}
+
+// add counts obj, as healthy if healthy is set.
+func (c *healthCount) add(obj v1.Object, healthy bool) {
+	c.Total++
+	if healthy {
+		c.Healthy++
+	}
+	c.addItem(obj, healthy)
+}
+
+// addItem lists obj, counting it as critical if it is unhealthy and matches a
+// critical rule, for callers that count Healthy and Total in other units.
+func (c *healthCount) addItem(obj v1.Object, healthy bool) {
+	item := newItemStatus(obj, healthy)
+	if !healthy && isCritical(obj) {
+		c.Critical++
+		item.Critical = true
+	}
+	c.Items = append(c.Items, item)
+}
+
+func (c healthCount) rate() float64 {
+	if c.Total == 0 {
+		return 1
+	}
+	return float64(c.Healthy) / float64(c.Total)
+}
+
+// color is fatal while any critical object is unhealthy, otherwise it follows
+// the healthy ratio.
+func (c healthCount) color(params url.Values) string {
+	if c.Critical > 0 {
+		return BADGE_COLOR_FATAL
+	}
+	return rateColor(c.rate(), params)
+}
+
+type thresholds struct {
+	Warn  float64
+	Fatal float64
+}
+
+// parseThresholds reads the warnThreshold/fatalThreshold overrides from params on
+// top of the configured defaults.
+func parseThresholds(params url.Values) (thresholds, error) {
+	t := thresholds{Warn: conf.WarnThreshold, Fatal: conf.FatalThreshold}
+	for name, target := range map[string]*float64{"warnThreshold": &t.Warn, "fatalThreshold": &t.Fatal} {
+		value := params.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return thresholds{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s: %s", name, value))
+		}
+		*target = parsed
+	}
+	if t.Fatal > t.Warn {
+		return thresholds{}, echo.NewHTTPError(http.StatusBadRequest, "fatalThreshold must not exceed warnThreshold")
+	}
+	return t, nil
+}
+
+func (t thresholds) color(rate float64) string {
+	if rate < t.Fatal {
+		return BADGE_COLOR_FATAL
+	} else if rate < t.Warn {
+		return BADGE_COLOR_WARN
+	}
+	return BADGE_COLOR_HEALTHY
+}
+
+// rateColor colors rate with the thresholds from params. Invalid overrides are
+// rejected before computing a badge, so they fall back to the defaults here.
+func rateColor(rate float64, params url.Values) string {
+	t, err := parseThresholds(params)
+	if err != nil {
+		t = thresholds{Warn: conf.WarnThreshold, Fatal: conf.FatalThreshold}
+	}
+	return t.color(rate)
+}
+
+type badge struct {
+	// Name identifies the badge (e.g. "pods" or "badge/frontend") in ?format=prom output.
+	Name      string
+	Label     string
+	Message   string
+	Color     string
+	Style     string
+	NamedLogo string
+	// LabelColor, LogoSVG, LogoColor and CacheSeconds are the optional shields
+	// endpoint fields, see applyBranding; Link only applies to ?format=svg.
+	LabelColor   string
+	LogoSVG      string
+	LogoColor    string
+	CacheSeconds int
+	Link         string
+	Count        healthCount
+	// Stale marks a last-known badge served because the Kubernetes API failed.
+	Stale bool
+	// Empty marks a count badge that matched no objects, see applyEmptyState.
+	Empty bool
+	// Level is healthy, warn or fatal as computed, before any color override.
+	Level string
+}
+
+// badgeLabel names the badge after kind, the environment and any namespace filter,
+// e.g. "pods(production/app1,app2)".
+func badgeLabel(kind string, params url.Values) string {
+	scope := conf.Env
+	if cluster := params.Get("cluster"); cluster != "" {
+		scope = cluster
+	}
+	if namespace := params.Get("namespace"); namespace != "" {
+		if scope != "" {
+			scope += "/"
+		}
+		scope += namespace
+	}
+	return fmt.Sprintf("%s(%s)", kind, scope)
+}
+
+// countMessage formats count as "healthy/total", followed by unit when set
+// and the critical objects, e.g. "3/4 deployed, 1 critical".
+func countMessage(count healthCount, unit string) string {
+	message := fmt.Sprintf("%d/%d", count.Healthy, count.Total)
+	if unit != "" {
+		message += " " + unit
+	}
+	if count.Critical > 0 {
+		message += fmt.Sprintf(", %d critical", count.Critical)
+	}
+	return message
+}
+
+func countBadge(label string, count healthCount, params url.Values) badge {
+	return badge{
+		Label:   label,
+		Message: countMessage(count, ""),
+		Color:   count.color(params),
+		Count:   count,
+		Empty:   count.Total == 0,
+	}
+}
+
+// renderBadge writes b in the shields.io endpoint schema. The counts are also
+// exposed as headers so monitors can read them without parsing the body, and an
+// ETag lets repeated requests for an unchanged badge end with 304.
+func renderBadge(ctx echo.Context, b badge) error {
+	contentType := echo.MIMEApplicationJSON
+	var r rendered
+	var body []byte
+	var err error
+	switch {
+	case wantsSVG(ctx):
+		contentType = "image/svg+xml"
+		r, err = renderCached("svg", b, func() ([]byte, error) { return renderSVG(b), nil })
+	case ctx.QueryParam("format") == "json":
+		body, err = json.Marshal(rawJSON(b))
+	case ctx.QueryParam("format") == "prom":
+		contentType = "text/plain; version=0.0.4; charset=utf-8"
+		body = renderProm(b)
+	case ctx.QueryParam("format") == "text":
+		contentType = echo.MIMETextPlainCharsetUTF8
+		body = []byte(b.Message)
+	case ctx.QueryParam("format") == "gitlab":
+		body, err = json.Marshal(gitlabJSON(ctx, b))
+	default:
+		r, err = renderCached("shields", b, func() ([]byte, error) { return renderShields(b) })
+	}
+	if err != nil {
+		return respondError(ctx, err)
+	}
+	if r.body == nil {
+		r = newRendered(body)
+	}
+	etag := r.etag
+
+	header := ctx.Response().Header()
+	header.Set("X-Badge-Healthy", strconv.Itoa(b.Count.Healthy))
+	header.Set("X-Badge-Total", strconv.Itoa(b.Count.Total))
+	if b.Stale {
+		header.Set("X-Badge-Stale", "true")
+	}
+	header.Set("ETag", etag)
+	if conf.CacheTTL > 0 {
+		header.Set("Cache-Control", fmt.Sprintf("max-age=%d", int(conf.CacheTTL.Seconds())))
+	}
+	if match := ctx.Request().Header.Get("If-None-Match"); match != "" && (match == "*" || strings.Contains(match, etag)) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+	return ctx.Blob(http.StatusOK, contentType, r.body)
+}
+
+// wantsSVG selects the native SVG rendering via ?format=svg or an Accept header
+// that prefers image/svg+xml over JSON.
+func wantsSVG(ctx echo.Context) bool {
+	switch ctx.QueryParam("format") {
+	case "svg":
+		return true
+	case "":
+		accept := ctx.Request().Header.Get("Accept")
+		return strings.Contains(accept, "image/svg+xml") && !strings.Contains(accept, "application/json")
+	}
+	return false
+}
+
+func badgeJSON(b badge) echo.Map {
+	body := echo.Map{
+		"schemaVersion":        1,
+		conf.BadgeLabelField:   b.Label,
+		conf.BadgeMessageField: b.Message,
+		conf.BadgeColorField:   b.Color,
+	}
+	if b.Style != "" {
+		body["style"] = b.Style
+	}
+	if b.NamedLogo != "" {
+		body["namedLogo"] = b.NamedLogo
+	}
+	if b.LogoSVG != "" {
+		body["logoSvg"] = b.LogoSVG
+	}
+	if b.LogoColor != "" {
+		body["logoColor"] = b.LogoColor
+	}
+	if b.LabelColor != "" {
+		body["labelColor"] = b.LabelColor
+	}
+	if b.CacheSeconds > 0 {
+		body["cacheSeconds"] = b.CacheSeconds
+	}
+	return body
+}
+
+// rawJSON returns the structured data behind b for ?format=json, with the
+// per-item statuses sorted by namespace and name.
+func rawJSON(b badge) echo.Map {
+	items := slices.Clone(b.Count.Items)
+	slices.SortFunc(items, func(a, b itemStatus) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	if items == nil {
+		items = []itemStatus{}
+	}
+	raw := echo.Map{
+		"badge":    b.Name,
+		"label":    b.Label,
+		"message":  b.Message,
+		"color":    b.Color,
+		"healthy":  b.Count.Healthy,
+		"total":    b.Count.Total,
+		"critical": b.Count.Critical,
+		"rate":     b.Count.rate(),
+		"items":    items,
+		"stale":    b.Stale,
+	}
+	if len(b.Count.Groups) > 0 {
+		raw["groups"] = b.Count.Groups
+	}
+	return raw
+}
+
+// renderProm writes the gauges of b in the Prometheus text exposition format
+// for ?format=prom, using the same metric names as /metrics.
+func renderProm(b badge) []byte {
+	var buf bytes.Buffer
+	gauges := []struct {
+		name, help string
+		value      float64
+	}{
+		{"healthy", "Number of healthy objects counted by the badge.", float64(b.Count.Healthy)},
+		{"total", "Number of objects counted by the badge.", float64(b.Count.Total)},
+		{"rate", "Ratio of healthy to total objects counted by the badge.", b.Count.rate()},
+	}
+	for _, g := range gauges {
+		name := METRICS_NAMESPACE + "_badge_" + g.name
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s gauge\n", name, g.help, name)
+		fmt.Fprintf(&buf, "%s{badge=%q} %s\n", name, b.Name, strconv.FormatFloat(g.value, 'g', -1, 64))
+	}
+	return buf.Bytes()
+}
+
+// applyPresentation overrides the label, per-level colors, style and logo of b
+// from the label, healthyColor/warnColor/fatalColor, style and logo parameters.
+func applyPresentation(b badge, params url.Values) badge {
+	b = applyEmptyState(b, params)
+	if label := params.Get("label"); label != "" {
+		b.Label = label
+	}
+	b.Level = colorLevels[b.Color]
+	b = applyMaintenance(b)
+	overrides := map[string]string{
+		BADGE_COLOR_HEALTHY: params.Get("healthyColor"),
+		BADGE_COLOR_WARN:    params.Get("warnColor"),
+		BADGE_COLOR_FATAL:   params.Get("fatalColor"),
+	}
+	if color := overrides[b.Color]; color != "" {
+		b.Color = color
+	}
+	b.Style = params.Get("style")
+	b.NamedLogo = params.Get("logo")
+	return applyBranding(b, params)
+}
+
+// respondError renders err as a shields-compatible error badge. The status
+// stays 200 so shields displays the badge; the intended status is kept in the
+// X-Badge-Error-Status header.
+func respondError(ctx echo.Context, err error) error {
+	code := http.StatusInternalServerError
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		code = httpErr.Code
+	}
+	ctx.Response().Header().Set("X-Badge-Error-Status", strconv.Itoa(code))
+	message := errorMessage(err)
+	if wantsSVG(ctx) {
+		return ctx.Blob(http.StatusOK, "image/svg+xml", renderSVG(badge{Label: "error", Message: message, Color: BADGE_COLOR_ERROR}))
+	}
+	if ctx.QueryParam("format") == "text" {
+		return ctx.String(http.StatusOK, "error: "+message)
+	}
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"schemaVersion":        1,
+		conf.BadgeLabelField:   "error",
+		conf.BadgeMessageField: message,
+		conf.BadgeColorField:   BADGE_COLOR_ERROR,
+		"isError":              true,
+	})
+}
+
+// errorMessage returns the client-facing message for err. Errors other than
+// *echo.HTTPError are logged and reduced to a short description so internal
+// details do not leak into badges.
+func errorMessage(err error) string {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return fmt.Sprint(httpErr.Message)
+	}
+	slog.Error(err.Error())
+	var netErr net.Error
+	switch {
+	case apierrors.IsUnauthorized(err), apierrors.IsForbidden(err):
+		return "access denied"
+	case apierrors.IsTooManyRequests(err):
+		return "cluster throttled"
+	case errors.Is(err, context.DeadlineExceeded), apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+		return "cluster timeout"
+	case errors.As(err, &netErr), apierrors.IsServiceUnavailable(err), apierrors.IsInternalError(err):
+		return "cluster unreachable"
+	}
+	return "internal error"
+}
+
+// matchAnnotation reports whether obj carries the annotation described by filter,
+// either "key" (present with any value) or "key=value". An empty filter matches everything.
+func matchAnnotation(obj v1.Object, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	key, value, hasValue := strings.Cut(filter, "=")
+	actual, ok := obj.GetAnnotations()[key]
+	if !ok {
+		return false
+	}
+	return !hasValue || actual == value
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMatchAnnotation(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Annotations: map[string]string{"team": "web", "oncall": ""}}}
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{filter: "", want: true},
+		{filter: "team=web", want: true},
+		{filter: "team=api", want: false},
+		{filter: "team", want: true},
+		{filter: "oncall", want: true},
+		{filter: "oncall=", want: true},
+		{filter: "owner", want: false},
+		{filter: "owner=web", want: false},
+	}
+	for _, tt := range tests {
+		if got := matchAnnotation(pod, tt.filter); got != tt.want {
+			t.Errorf("matchAnnotation(%q) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestBadgeCountHeaders(t *testing.T) {
+	setupTest(t, nil, testPod("web", true, "", 0), testPod("api", false, "", 0), testPod("db", true, "", 0))
+	for _, path := range []string{"/pods", "/pods?format=svg", "/pods?format=text"} {
+		rec := get(t, path)
+		if got := rec.Header().Get("X-Badge-Healthy"); got != "2" {
+			t.Errorf("%s: X-Badge-Healthy = %q, want 2", path, got)
+		}
+		if got := rec.Header().Get("X-Badge-Total"); got != "3" {
+			t.Errorf("%s: X-Badge-Total = %q, want 3", path, got)
+		}
+	}
+
+	rec := get(t, "/pods?selector=a%20b")
+	if got := rec.Header().Get("X-Badge-Error-Status"); got != "400" {
+		t.Errorf("X-Badge-Error-Status = %q, want 400", got)
+	}
+	if got := rec.Header().Get("X-Badge-Total"); got != "" {
+		t.Errorf("X-Badge-Total = %q on an error", got)
+	}
+}
+
+func TestRemappedBadgeFields(t *testing.T) {
+	setupTest(t, map[string]string{
+		"APP_ENV":                 "prod",
+		"APP_BADGE_LABEL_FIELD":   "title",
+		"APP_BADGE_MESSAGE_FIELD": "text",
+		"APP_BADGE_COLOR_FIELD":   "colour",
+	}, testPod("web", true, "", 0))
+	for _, tt := range []struct{ path, label string }{
+		{path: "/pods", label: "pods(prod)"},
+		{path: "/pods?selector=a%20b", label: "error"},
+	} {
+		var got map[string]any
+		if err := json.Unmarshal(get(t, tt.path).Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got["title"] != tt.label || got["text"] == nil || got["colour"] == nil {
+			t.Errorf("%s: body = %v, want title, text and colour", tt.path, got)
+		}
+		for _, field := range []string{"label", "message", "color"} {
+			if _, ok := got[field]; ok {
+				t.Errorf("%s: body still has %s", tt.path, field)
+			}
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/labstack/echo/v4"
+	"sigs.k8s.io/yaml"
+)
+
+// badgeDef is a named badge declared in the APP_CONFIG file and served at /badge/{name}.
+type badgeDef struct {
+	Name           string   `json:"name"`
+	Resource       string   `json:"resource"`
+	Namespaces     []string `json:"namespaces"`
+	Selector       string   `json:"selector"`
+	Label          string   `json:"label"`
+	WarnThreshold  *float64 `json:"warnThreshold"`
+	FatalThreshold *float64 `json:"fatalThreshold"`
+	HealthyColor   string   `json:"healthyColor"`
+	WarnColor      string   `json:"warnColor"`
+	FatalColor     string   `json:"fatalColor"`
+	LabelColor     string   `json:"labelColor"`
+	// RefreshInterval overrides APP_REFRESH_INTERVAL for this badge, e.g. "5m".
+	RefreshInterval string `json:"refreshInterval"`
+	// Params holds any other query parameter the resource understands, e.g. mode.
+	Params map[string]string `json:"params"`
+	// Expression is a CEL health rule replacing Resource: the objects of the
+	// group, version and resource params are listed and healthy when it holds.
+	Expression string `json:"expression"`
+	// Impersonate evaluates the badge as this user, e.g.
+	// "system:serviceaccount:team-a:badges", so it only sees what their RBAC allows.
+	Impersonate string `json:"impersonate"`
+	// Message is a Go template replacing the computed message, rendered over
+	// messageData, e.g. "{{.Healthy}}/{{.Total}} healthy ({{.Percent}}%)".
+	Message string `json:"message"`
+
+	program cel.Program
+	message *template.Template
+}
+
+type badgeConfig struct {
+	Badges []badgeDef `json:"badges"`
+	// Cluster replaces the default checks of the /cluster badge.
+	Cluster []clusterCheck `json:"cluster"`
+	Probes  []probeDef     `json:"probes"`
+	// Maintenance declares recurring maintenance windows.
+	Maintenance []maintenanceWindow `json:"maintenance"`
+	// Critical marks namespaces and selectors whose unhealthy objects turn
+	// aggregate badges red on their own.
+	Critical []criticalRule `json:"critical"`
+	// Profiles declares the pod health definitions selectable with ?profile=.
+	Profiles []healthProfile `json:"profiles"`
+}
+
+// badgeDefs is swapped atomically on reload so in-flight requests keep a consistent view.
+var badgeDefs atomic.Pointer[map[string]badgeDef]
+
+func init() {
+	badgeDefs.Store(&map[string]badgeDef{})
+}
+
+// reloadBadgeDefs re-reads path, merges in the ConfigMaps and Secrets selected
+// by APP_CONFIG_SOURCE_SELECTOR and swaps in the definitions; on error the
+// previous definitions stay in place. path may be empty when only those
+// sources are used.
+func reloadBadgeDefs(path string) error {
+	config := badgeConfig{}
+	if path != "" {
+		var err error
+		if config, err = readBadgeConfig(path); err != nil {
+			return err
+		}
+	}
+	defs, config, err := validateBadgeConfig(path, config)
+	if err != nil {
+		return err
+	}
+	if configSourcesStarted() {
+		defs, config, _ = mergeConfigSources(defs, config)
+	}
+	probes := map[string]probeDef{}
+	for _, probe := range config.Probes {
+		probes[probe.Name] = probe
+	}
+	profiles := map[string]healthProfile{}
+	for _, profile := range config.Profiles {
+		profiles[profile.Name] = profile
+	}
+	badgeDefs.Store(&defs)
+	clusterChecks.Store(&config.Cluster)
+	probeDefs.Store(&probes)
+	maintenanceWindows.Store(&config.Maintenance)
+	criticalRules.Store(&config.Critical)
+	healthProfiles.Store(&profiles)
+	slog.Info("badge config loaded", "path", path, "badges", len(defs), "cluster_checks", len(config.Cluster), "probes", len(probes), "maintenance_windows", len(config.Maintenance))
+	return nil
+}
+
+// watchBadgeDefs reloads path whenever its modification time changes. ConfigMap
+// volumes swap a symlink, which os.Stat follows.
+func watchBadgeDefs(ctx context.Context, path string, interval time.Duration) {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			slog.Error("badge config stat failed", "path", path, "error", err.Error())
+			continue
+		}
+		if info.ModTime().Equal(lastMod) {
+			continue
+		}
+		lastMod = info.ModTime()
+		if err := reloadBadgeDefs(path); err != nil {
+			slog.Error("badge config reload failed", "path", path, "error", err.Error())
+		}
+	}
+}
+
+// handleConfigReload reloads the badge definitions now; it requires one of
+// APP_AUTH_TOKENS.
+func handleConfigReload(ctx echo.Context) error {
+	if conf.ConfigFile == "" && !configSourcesStarted() {
+		return respondError(ctx, echo.NewHTTPError(http.StatusNotFound, "no config file configured"))
+	}
+	if err := reloadBadgeDefs(conf.ConfigFile); err != nil {
+		return respondError(ctx, echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, echo.Map{"badges": len(*badgeDefs.Load())})
+}
+
+func loadBadgeDefs(path string) (map[string]badgeDef, badgeConfig, error) {
+	config, err := readBadgeConfig(path)
+	if err != nil {
+		return nil, badgeConfig{}, err
+	}
+	return validateBadgeConfig(path, config)
+}
+
+func readBadgeConfig(path string) (badgeConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return badgeConfig{}, err
+	}
+	return parseBadgeConfig(path, data)
+}
+
+// parseBadgeConfig parses data read from source, a file path or a ConfigMap or
+// Secret, which prefixes the errors.
+func parseBadgeConfig(source string, data []byte) (badgeConfig, error) {
+	var config badgeConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return badgeConfig{}, fmt.Errorf("%s: %w", source, err)
+	}
+	return config, nil
+}
+
+// validateBadgeConfig checks config as a whole, since cluster checks and
+// profiles are referenced across sections, and indexes its badges by name.
+func validateBadgeConfig(path string, config badgeConfig) (map[string]badgeDef, badgeConfig, error) {
+	var err error
+	defs := map[string]badgeDef{}
+	for _, def := range config.Badges {
+		if def.Name == "" {
+			return nil, badgeConfig{}, fmt.Errorf("%s: badge without a name", path)
+		}
+		if _, ok := defs[def.Name]; ok {
+			return nil, badgeConfig{}, fmt.Errorf("%s: duplicate badge %s", path, def.Name)
+		}
+		if def.Expression != "" {
+			if def.Resource != "" {
+				return nil, badgeConfig{}, fmt.Errorf("%s: badge %s: expression and resource are mutually exclusive", path, def.Name)
+			}
+			if def.Params["version"] == "" || def.Params["resource"] == "" {
+				return nil, badgeConfig{}, fmt.Errorf("%s: badge %s: expression requires version and resource params", path, def.Name)
+			}
+			if def.program, err = compileExpression(def.Expression); err != nil {
+				return nil, badgeConfig{}, fmt.Errorf("%s: badge %s: invalid expression: %w", path, def.Name, err)
+			}
+		} else if _, ok := findEvaluator(def.Resource); !ok {
+			return nil, badgeConfig{}, fmt.Errorf("%s: badge %s: unknown or disabled resource %q", path, def.Name, def.Resource)
+		}
+		if _, err := parseThresholds(def.params()); err != nil {
+			return nil, badgeConfig{}, fmt.Errorf("%s: badge %s: %s", path, def.Name, errorMessage(err))
+		}
+		if _, err := parseEmptyState(def.params()); err != nil {
+			return nil, badgeConfig{}, fmt.Errorf("%s: badge %s: %s", path, def.Name, errorMessage(err))
+		}
+		if _, err := parseCacheSeconds(def.params()); err != nil {
+			return nil, badgeConfig{}, fmt.Errorf("%s: badge %s: %s", path, def.Name, errorMessage(err))
+		}
+		if def.Message != "" {
+			if def.message, err = parseMessageTemplate(def.Name, def.Message); err != nil {
+				return nil, badgeConfig{}, fmt.Errorf("%s: badge %s: invalid message: %w", path, def.Name, err)
+			}
+		}
+		if def.RefreshInterval != "" {
+			if interval, err := time.ParseDuration(def.RefreshInterval); err != nil || interval <= 0 {
+				return nil, badgeConfig{}, fmt.Errorf("%s: badge %s: invalid refreshInterval %q", path, def.Name, def.RefreshInterval)
+			}
+		}
+		defs[def.Name] = def
+	}
+	for _, check := range config.Cluster {
+		if err := check.validate(defs); err != nil {
+			return nil, badgeConfig{}, fmt.Errorf("%s: cluster check %s: %w", path, check.name(), err)
+		}
+	}
+	probes := map[string]bool{}
+	for _, probe := range config.Probes {
+		if probe.Name == "" {
+			return nil, badgeConfig{}, fmt.Errorf("%s: probe without a name", path)
+		}
+		if probes[probe.Name] {
+			return nil, badgeConfig{}, fmt.Errorf("%s: duplicate probe %s", path, probe.Name)
+		}
+		if err := probe.validate(); err != nil {
+			return nil, badgeConfig{}, fmt.Errorf("%s: probe %s: %w", path, probe.Name, err)
+		}
+		probes[probe.Name] = true
+	}
+	for i := range config.Maintenance {
+		if err := config.Maintenance[i].parse(); err != nil {
+			return nil, badgeConfig{}, fmt.Errorf("%s: maintenance window %s: %w", path, config.Maintenance[i].Name, err)
+		}
+	}
+	for i := range config.Critical {
+		if err := config.Critical[i].parse(); err != nil {
+			return nil, badgeConfig{}, fmt.Errorf("%s: critical rule %d: %w", path, i+1, err)
+		}
+	}
+	profiles := map[string]bool{}
+	for i := range config.Profiles {
+		profile := &config.Profiles[i]
+		if err := profile.parse(); err != nil {
+			return nil, badgeConfig{}, fmt.Errorf("%s: profile %s: %w", path, profile.Name, err)
+		}
+		if profiles[profile.Name] {
+			return nil, badgeConfig{}, fmt.Errorf("%s: duplicate profile %s", path, profile.Name)
+		}
+		profiles[profile.Name] = true
+	}
+	for _, def := range defs {
+		if profile := def.Params["profile"]; profile != "" && !profiles[profile] {
+			return nil, badgeConfig{}, fmt.Errorf("%s: badge %s: unknown profile %q", path, def.Name, profile)
+		}
+	}
+	return defs, config, nil
+}
+
+// params translates the definition into the query parameters the resource's badge understands.
+func (d badgeDef) params() url.Values {
+	params := url.Values{}
+	for key, value := range d.Params {
+		params.Set(key, value)
+	}
+	set := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	set("namespace", strings.Join(d.Namespaces, ","))
+	set("selector", d.Selector)
+	set("label", d.Label)
+	set("healthyColor", d.HealthyColor)
+	set("warnColor", d.WarnColor)
+	set("fatalColor", d.FatalColor)
+	set("labelColor", d.LabelColor)
+	if d.WarnThreshold != nil {
+		params.Set("warnThreshold", strconv.FormatFloat(*d.WarnThreshold, 'f', -1, 64))
+	}
+	if d.FatalThreshold != nil {
+		params.Set("fatalThreshold", strconv.FormatFloat(*d.FatalThreshold, 'f', -1, 64))
+	}
+	return params
+}
+
+// evaluate returns the badge function of the definition: its expression when
+// set, otherwise the evaluator of its resource, called as Impersonate if set
+// and with its message rendered from the Message template.
+func (d badgeDef) evaluate() (badgeFunc, bool) {
+	var compute badgeFunc
+	if d.program != nil {
+		compute = expressionBadge(d)
+	} else if e, ok := findEvaluator(d.Resource); ok {
+		compute = e.Evaluate
+	} else {
+		return nil, false
+	}
+	if d.Impersonate != "" {
+		evaluate := compute
+		compute = func(ctx context.Context, params url.Values) (badge, error) {
+			return evaluate(withImpersonation(ctx, d.Impersonate), params)
+		}
+	}
+	if d.message == nil {
+		return compute, true
+	}
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		b, err := compute(ctx, params)
+		if err != nil {
+			return b, err
+		}
+		if b.Message, err = renderMessage(d.message, b); err != nil {
+			return badge{}, err
+		}
+		return b, nil
+	}, true
+}
+
+// handleNamedBadge serves a configured badge. Request parameters such as format
+// or nocache pass through, but cannot override the definition.
+func handleNamedBadge(ctx echo.Context) error {
+	name := ctx.Param("name")
+	def, ok := (*badgeDefs.Load())[name]
+	if !ok {
+		return respondError(ctx, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unknown badge: %s", name)))
+	}
+	compute, ok := def.evaluate()
+	if !ok {
+		return respondError(ctx, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unknown badge: %s", name)))
+	}
+	params := url.Values{}
+	for key, values := range ctx.QueryParams() {
+		params[key] = values
+	}
+	for key, values := range def.params() {
+		params[key] = values
+	}
+	return serveBadge(ctx, "badge/"+name, params, compute)
+}
@@ -0,0 +1,211 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: badge.proto
+
+package badgepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	BadgeService_GetBadge_FullMethodName   = "/k8sstatusbadge.v1.BadgeService/GetBadge"
+	BadgeService_ListBadges_FullMethodName = "/k8sstatusbadge.v1.BadgeService/ListBadges"
+	BadgeService_WatchBadge_FullMethodName = "/k8sstatusbadge.v1.BadgeService/WatchBadge"
+)
+
+// BadgeServiceClient is the client API for BadgeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BadgeService serves the badges of the HTTP endpoints as typed messages.
+type BadgeServiceClient interface {
+	// GetBadge computes one badge, e.g. "pods" or "badge/frontend".
+	GetBadge(ctx context.Context, in *GetBadgeRequest, opts ...grpc.CallOption) (*Badge, error)
+	// ListBadges computes every enabled resource badge and named badge.
+	ListBadges(ctx context.Context, in *ListBadgesRequest, opts ...grpc.CallOption) (*ListBadgesResponse, error)
+	// WatchBadge streams a badge whenever its level, color or message changes.
+	WatchBadge(ctx context.Context, in *WatchBadgeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Badge], error)
+}
+
+type badgeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBadgeServiceClient(cc grpc.ClientConnInterface) BadgeServiceClient {
+	return &badgeServiceClient{cc}
+}
+
+func (c *badgeServiceClient) GetBadge(ctx context.Context, in *GetBadgeRequest, opts ...grpc.CallOption) (*Badge, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Badge)
+	err := c.cc.Invoke(ctx, BadgeService_GetBadge_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *badgeServiceClient) ListBadges(ctx context.Context, in *ListBadgesRequest, opts ...grpc.CallOption) (*ListBadgesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBadgesResponse)
+	err := c.cc.Invoke(ctx, BadgeService_ListBadges_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *badgeServiceClient) WatchBadge(ctx context.Context, in *WatchBadgeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Badge], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BadgeService_ServiceDesc.Streams[0], BadgeService_WatchBadge_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchBadgeRequest, Badge]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BadgeService_WatchBadgeClient = grpc.ServerStreamingClient[Badge]
+
+// BadgeServiceServer is the server API for BadgeService service.
+// All implementations must embed UnimplementedBadgeServiceServer
+// for forward compatibility.
+//
+// BadgeService serves the badges of the HTTP endpoints as typed messages.
+type BadgeServiceServer interface {
+	// GetBadge computes one badge, e.g. "pods" or "badge/frontend".
+	GetBadge(context.Context, *GetBadgeRequest) (*Badge, error)
+	// ListBadges computes every enabled resource badge and named badge.
+	ListBadges(context.Context, *ListBadgesRequest) (*ListBadgesResponse, error)
+	// WatchBadge streams a badge whenever its level, color or message changes.
+	WatchBadge(*WatchBadgeRequest, grpc.ServerStreamingServer[Badge]) error
+	mustEmbedUnimplementedBadgeServiceServer()
+}
+
+// UnimplementedBadgeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBadgeServiceServer struct{}
+
+func (UnimplementedBadgeServiceServer) GetBadge(context.Context, *GetBadgeRequest) (*Badge, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBadge not implemented")
+}
+func (UnimplementedBadgeServiceServer) ListBadges(context.Context, *ListBadgesRequest) (*ListBadgesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBadges not implemented")
+}
+func (UnimplementedBadgeServiceServer) WatchBadge(*WatchBadgeRequest, grpc.ServerStreamingServer[Badge]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchBadge not implemented")
+}
+func (UnimplementedBadgeServiceServer) mustEmbedUnimplementedBadgeServiceServer() {}
+func (UnimplementedBadgeServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeBadgeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BadgeServiceServer will
+// result in compilation errors.
+type UnsafeBadgeServiceServer interface {
+	mustEmbedUnimplementedBadgeServiceServer()
+}
+
+func RegisterBadgeServiceServer(s grpc.ServiceRegistrar, srv BadgeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedBadgeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BadgeService_ServiceDesc, srv)
+}
+
+func _BadgeService_GetBadge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBadgeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BadgeServiceServer).GetBadge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BadgeService_GetBadge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BadgeServiceServer).GetBadge(ctx, req.(*GetBadgeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BadgeService_ListBadges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBadgesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BadgeServiceServer).ListBadges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BadgeService_ListBadges_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BadgeServiceServer).ListBadges(ctx, req.(*ListBadgesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BadgeService_WatchBadge_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchBadgeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BadgeServiceServer).WatchBadge(m, &grpc.GenericServerStream[WatchBadgeRequest, Badge]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BadgeService_WatchBadgeServer = grpc.ServerStreamingServer[Badge]
+
+// BadgeService_ServiceDesc is the grpc.ServiceDesc for BadgeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BadgeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "k8sstatusbadge.v1.BadgeService",
+	HandlerType: (*BadgeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBadge",
+			Handler:    _BadgeService_GetBadge_Handler,
+		},
+		{
+			MethodName: "ListBadges",
+			Handler:    _BadgeService_ListBadges_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchBadge",
+			Handler:       _BadgeService_WatchBadge_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "badge.proto",
+}
@@ -0,0 +1,4 @@
+// Package badgepb holds the generated gRPC API of the badge server.
+package badgepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative badge.proto
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+)
+
+const BADGE_STATUS_KIND = "BadgeStatus"
+
+// badgeStatusGVR is defined by crd/badgestatuses.yaml.
+var badgeStatusGVR = schema.GroupVersionResource{Group: "badge.piny940.dev", Version: "v1alpha1", Resource: "badgestatuses"}
+
+var (
+	writtenStatusesMu sync.Mutex
+	// writtenStatuses is the last status written per badge, so unchanged
+	// badges cost no API calls.
+	writtenStatuses = map[string]map[string]any{}
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// badgeStatusName maps a badge name such as "badge/web" to a valid object name.
+func badgeStatusName(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(strings.ReplaceAll(name, "/", ".")), "-"), ".-")
+}
+
+// writeBadgeStatus records b into the BadgeStatus object of name in
+// APP_STATUS_NAMESPACE, retrying on conflicts, and emits an Event on the
+// object when its level changes. Only the leader writes.
+func writeBadgeStatus(ctx context.Context, name string, b badge) {
+	if !leading.Load() || b.Level == "" {
+		return
+	}
+	status := map[string]any{
+		"label":   b.Label,
+		"message": b.Message,
+		"color":   b.Color,
+		"level":   b.Level,
+		"healthy": int64(b.Count.Healthy),
+		"total":   int64(b.Count.Total),
+	}
+	writtenStatusesMu.Lock()
+	previous, seen := writtenStatuses[name]
+	writtenStatusesMu.Unlock()
+	if seen && equalStatus(previous, status) {
+		return
+	}
+	ctx, cancel := withK8sTimeout(ctx)
+	defer cancel()
+	client := dynamicClient.Resource(badgeStatusGVR).Namespace(conf.StatusNamespace)
+	objectName := badgeStatusName(name)
+	var previousLevel string
+	var written *unstructured.Unstructured
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := client.Get(ctx, objectName, v1.GetOptions{})
+		create := apierrors.IsNotFound(err)
+		if create {
+			obj = &unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": badgeStatusGVR.GroupVersion().String(),
+				"kind":       BADGE_STATUS_KIND,
+				"metadata":   map[string]any{"name": objectName, "namespace": conf.StatusNamespace},
+				"spec":       map[string]any{"badge": name},
+			}}
+		} else if err != nil {
+			return err
+		}
+		previousLevel, _, _ = unstructured.NestedString(obj.Object, "status", "level")
+		next := map[string]any{"lastEvaluated": time.Now().UTC().Format(time.RFC3339)}
+		for key, value := range status {
+			next[key] = value
+		}
+		next["lastTransitionTime"], _, _ = unstructured.NestedString(obj.Object, "status", "lastTransitionTime")
+		if previousLevel != b.Level || next["lastTransitionTime"] == "" {
+			next["lastTransitionTime"] = next["lastEvaluated"]
+		}
+		obj.Object["status"] = next
+		if create {
+			written, err = client.Create(ctx, obj, v1.CreateOptions{})
+		} else {
+			written, err = client.Update(ctx, obj, v1.UpdateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		slog.Error("badge status write failed", "badge", name, "error", err.Error())
+		return
+	}
+	writtenStatusesMu.Lock()
+	writtenStatuses[name] = status
+	writtenStatusesMu.Unlock()
+	if conf.StatusEvents && previousLevel != "" && previousLevel != b.Level {
+		emitLevelEvent(ctx, written, name, previousLevel, b)
+	}
+}
+
+func equalStatus(a, b map[string]any) bool {
+	for key, value := range b {
+		if a[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// emitLevelEvent records a level transition on the BadgeStatus object, as a
+// Warning unless the badge recovered or went into maintenance.
+func emitLevelEvent(ctx context.Context, obj *unstructured.Unstructured, name, previous string, b badge) {
+	eventType := corev1.EventTypeWarning
+	if b.Level == BADGE_LEVEL_HEALTHY || b.Level == BADGE_LEVEL_MAINTENANCE {
+		eventType = corev1.EventTypeNormal
+	}
+	now := v1.Now()
+	event := &corev1.Event{
+		ObjectMeta: v1.ObjectMeta{GenerateName: obj.GetName() + ".", Namespace: obj.GetNamespace()},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      obj.GetAPIVersion(),
+			Kind:            obj.GetKind(),
+			Namespace:       obj.GetNamespace(),
+			Name:            obj.GetName(),
+			UID:             obj.GetUID(),
+			ResourceVersion: obj.GetResourceVersion(),
+		},
+		Reason:         "LevelChanged",
+		Message:        fmt.Sprintf("%s is %s: %s (was %s)", name, b.Level, b.Message, previous),
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: "k8s-status-badge"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := k8sClient.CoreV1().Events(obj.GetNamespace()).Create(ctx, event, v1.CreateOptions{}); err != nil {
+		slog.Error("badge status event failed", "badge", name, "error", err.Error())
+	}
+}
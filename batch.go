@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/sync/errgroup"
+)
+
+// BATCH_MAX_ITEMS caps the badges of one batch; APP_BATCH_CONCURRENCY only
+// limits how many are evaluated at a time.
+const BATCH_MAX_ITEMS = 100
+
+type batchQuery struct {
+	// Resource names an evaluator such as pods, or a configured badge as
+	// badge/<name>.
+	Resource string `json:"resource"`
+	// Params are those of the badge at /<resource>; with APP_SIGNING_SECRET
+	// they carry the sig (and expires) of that URL.
+	Params map[string]string `json:"params"`
+}
+
+// batchResult computes one query; its failure is reported in the result so
+// the other badges of the batch are still returned.
+func batchResult(ctx context.Context, query batchQuery) echo.Map {
+	params := batchParams(query)
+	name, compute := query.Resource, badgeFunc(nil)
+	if badgeName, ok := strings.CutPrefix(query.Resource, "badge/"); ok {
+		if def, ok := (*badgeDefs.Load())[badgeName]; ok {
+			compute, _ = def.evaluate()
+			for key, values := range def.params() {
+				params[key] = values
+			}
+		}
+	} else if e, ok := findEvaluator(query.Resource); ok {
+		name, compute = e.Name(), e.Evaluate
+	}
+	if compute == nil {
+		return echo.Map{"resource": query.Resource, "error": "unknown resource"}
+	}
+	b, err := computeBadge(ctx, name, params, compute)
+	if err != nil {
+		return echo.Map{"resource": query.Resource, "error": errorMessage(err)}
+	}
+	result := badgeJSON(b)
+	result["resource"] = query.Resource
+	return result
+}
+
+func batchParams(query batchQuery) url.Values {
+	params := url.Values{}
+	for key, value := range query.Params {
+		params.Set(key, value)
+	}
+	return params
+}
+
+// computeBatch computes queries with at most APP_BATCH_CONCURRENCY at a time,
+// returning the results in the order of queries. With verify, queries whose
+// params are not signed for /<resource> are rejected.
+func computeBatch(ctx context.Context, queries []batchQuery, verify bool) []echo.Map {
+	results := make([]echo.Map, len(queries))
+	var group errgroup.Group
+	group.SetLimit(max(conf.BatchConcurrency, 1))
+	for i, query := range queries {
+		if verify {
+			if err := verifySignature(conf.SigningSecret, "/"+query.Resource, batchParams(query)); err != nil {
+				results[i] = echo.Map{"resource": query.Resource, "error": errorMessage(err)}
+				continue
+			}
+		}
+		group.Go(func() error {
+			results[i] = batchResult(ctx, query)
+			return nil
+		})
+	}
+	group.Wait()
+	return results
+}
+
+// handleBatch computes the posted queries. Unless the request presents one of
+// APP_AUTH_TOKENS, each item must be signed like its single-badge URL when
+// APP_SIGNING_SECRET is set; items that are not fail on their own. Batches over
+// BATCH_MAX_ITEMS are rejected before any signature is checked.
+func handleBatch(ctx echo.Context) error {
+	var queries []batchQuery
+	if err := ctx.Bind(&queries); err != nil {
+		return respondError(ctx, err)
+	}
+	if len(queries) > BATCH_MAX_ITEMS {
+		return respondError(ctx, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("too many badges: %d, at most %d", len(queries), BATCH_MAX_ITEMS)))
+	}
+	verify := conf.SigningSecret != "" && !validToken(requestToken(ctx), conf.AuthTokens)
+	return ctx.JSON(http.StatusOK, computeBatch(ctx.Request().Context(), queries, verify))
+}
+
+// handleBadges serves GET /badges?names=pods,nodes,badge/frontend, passing the
+// other query parameters, e.g. namespace, to every badge.
+func handleBadges(ctx echo.Context) error {
+	names := splitList(ctx.QueryParam("names"))
+	if len(names) == 0 {
+		return respondError(ctx, echo.NewHTTPError(http.StatusBadRequest, "names is required"))
+	}
+	if len(names) > BATCH_MAX_ITEMS {
+		return respondError(ctx, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("too many badges: %d, at most %d", len(names), BATCH_MAX_ITEMS)))
+	}
+	params := map[string]string{}
+	for key := range ctx.QueryParams() {
+		if key != "names" {
+			params[key] = ctx.QueryParam(key)
+		}
+	}
+	queries := make([]batchQuery, len(names))
+	for i, name := range names {
+		queries[i] = batchQuery{Resource: name, Params: params}
+	}
+	return ctx.JSON(http.StatusOK, computeBatch(ctx.Request().Context(), queries, false))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func postBatch(t *testing.T, path, body string) []map[string]any {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	newServer(conf).ServeHTTP(rec, req)
+	var results []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("%s: %v", rec.Body, err)
+	}
+	return results
+}
+
+func TestBatchPodsAndNodes(t *testing.T) {
+	setupTest(t, map[string]string{"APP_ENV": "prod"},
+		testPod("web", true, "", 0), testPod("api", false, "", 0),
+		testNode("n1", true), testNode("n2", true), testNode("n3", false),
+	)
+	results := postBatch(t, "/batch", `[
+		{"resource": "pods"},
+		{"resource": "nodes"},
+		{"resource": "pods", "params": {"namespace": "other"}},
+		{"resource": "volumes"}
+	]`)
+	want := []struct{ resource, label, message, err string }{
+		{resource: "pods", label: "pods(prod)", message: "1/2"},
+		{resource: "nodes", label: "nodes(prod)", message: "2/3 ready"},
+		{resource: "pods", label: "pods(prod/other)", message: "no pods"},
+		{resource: "volumes", err: "unknown resource"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		got := results[i]
+		if got["resource"] != w.resource {
+			t.Errorf("result %d: resource = %v, want %s", i, got["resource"], w.resource)
+		}
+		if w.err != "" {
+			if got["error"] != w.err {
+				t.Errorf("result %d: error = %v, want %s", i, got["error"], w.err)
+			}
+			continue
+		}
+		if got["label"] != w.label || got["message"] != w.message {
+			t.Errorf("result %d: %v %v, want %s %s", i, got["label"], got["message"], w.label, w.message)
+		}
+	}
+}
+
+func TestBatchSignedItems(t *testing.T) {
+	setupTest(t, map[string]string{"APP_SIGNING_SECRET": "s3cret", "APP_AUTH_TOKENS": "t0ken"}, testPod("web", true, "", 0), testNode("n1", true))
+	sig := signature("s3cret", "/pods", url.Values{"namespace": {"default"}})
+	body := fmt.Sprintf(`[
+		{"resource": "pods", "params": {"namespace": "default", "sig": %q}},
+		{"resource": "pods", "params": {"namespace": "kube-system", "sig": %[1]q}},
+		{"resource": "nodes"}
+	]`, sig)
+
+	want := []string{"", "invalid signature", "signature required"}
+	for i, result := range postBatch(t, "/batch", body) {
+		if result["error"] != nil && result["error"] != want[i] || result["error"] == nil && want[i] != "" {
+			t.Errorf("result %d: error = %v, want %q", i, result["error"], want[i])
+		}
+	}
+	for i, result := range postBatch(t, "/batch?token=t0ken", body) {
+		if result["error"] != nil {
+			t.Errorf("result %d with a token: error = %v", i, result["error"])
+		}
+	}
+}
+
+// batchOf returns a batch of n copies of item.
+func batchOf(item string, n int) string {
+	return "[" + strings.TrimSuffix(strings.Repeat(item+",", n), ",") + "]"
+}
+
+func TestBatchTooManyItems(t *testing.T) {
+	signed := fmt.Sprintf(`{"resource": "pods", "params": {"sig": %q}}`, signature("s3cret", "/pods", url.Values{}))
+	for _, tt := range []struct {
+		name, item string
+		env        map[string]string
+	}{
+		{name: "unsigned", item: `{"resource": "pods"}`},
+		// Signed items must not cost a signature check each before the limit.
+		{name: "signed", item: signed, env: map[string]string{"APP_SIGNING_SECRET": "s3cret"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, tt.env)
+			req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(batchOf(tt.item, BATCH_MAX_ITEMS+1)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			newServer(conf).ServeHTTP(rec, req)
+			if got := rec.Header().Get("X-Badge-Error-Status"); got != "400" {
+				t.Errorf("X-Badge-Error-Status = %q, want 400: %s", got, rec.Body)
+			}
+			if results := postBatch(t, "/batch", batchOf(tt.item, BATCH_MAX_ITEMS)); len(results) != BATCH_MAX_ITEMS {
+				t.Errorf("got %d results for a full batch, want %d", len(results), BATCH_MAX_ITEMS)
+			}
+		})
+	}
+}
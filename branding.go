@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// badgeLogoSVG is the SVG read from APP_BADGE_LOGO_SVG, shown on every badge
+// without a named ?logo=.
+var badgeLogoSVG string
+
+// loadBadgeLogo reads the custom logo at startup; shields expects the SVG
+// markup itself in logoSvg.
+func loadBadgeLogo(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Contains(data, []byte("<svg")) {
+		return fmt.Errorf("%s: not an SVG image", path)
+	}
+	badgeLogoSVG = string(bytes.TrimSpace(data))
+	return nil
+}
+
+// parseCacheSeconds reads how long shields may cache the badge from the
+// cacheSeconds param, falling back to APP_BADGE_CACHE_SECONDS; 0 leaves it
+// to shields.
+func parseCacheSeconds(params url.Values) (int, error) {
+	value := params.Get("cacheSeconds")
+	if value == "" {
+		return conf.BadgeCacheSeconds, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid cacheSeconds: %s", value))
+	}
+	return seconds, nil
+}
+
+// applyBranding sets the shields extras of b: label and logo colors, the
+// custom logo, cacheSeconds and the link of the SVG rendering.
+func applyBranding(b badge, params url.Values) badge {
+	b.LabelColor = cmp.Or(params.Get("labelColor"), conf.BadgeLabelColor)
+	b.LogoColor = params.Get("logoColor")
+	if b.NamedLogo == "" {
+		b.LogoSVG = badgeLogoSVG
+	}
+	b.CacheSeconds, _ = parseCacheSeconds(params)
+	b.Link = cmp.Or(params.Get("link"), conf.BadgeLinkURL)
+	return b
+}
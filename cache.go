@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+type cacheEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+type ttlCache struct {
+	name    string
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	// loads deduplicates concurrent loads of the same key.
+	loads singleflight.Group
+}
+
+func newTTLCache(name string) *ttlCache {
+	return &ttlCache{name: name, entries: map[string]cacheEntry{}}
+}
+
+func (c *ttlCache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache) set(key string, value any, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry{value: value, expiresAt: time.Now().Add(ttl)}
+}
+
+func (c *ttlCache) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// sweep drops the entries expired before now. Keys come from request params,
+// so without it a cache would keep every key ever requested.
+func (c *ttlCache) sweep(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// expirations returns the expiry of every entry, expired ones included.
+func (c *ttlCache) expirations() map[string]time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expirations := make(map[string]time.Time, len(c.entries))
+	for key, entry := range c.entries {
+		expirations[key] = entry.expiresAt
+	}
+	return expirations
+}
+
+var listCache = newTTLCache("list")
+var badgeCache = newTTLCache("badge")
+
+// sweepCaches drops the expired entries of listCache and badgeCache every
+// interval until ctx is done.
+func sweepCaches(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			listCache.sweep(now)
+			badgeCache.sweep(now)
+		}
+	}
+}
+
+// cached serves load's result from c for conf.CacheTTL. With noCache the cached
+// value is ignored but the fresh result still replaces it. Concurrent misses for
+// the same key share a single call to load.
+func cached[T any](ctx context.Context, c *ttlCache, key string, noCache bool, load func() (T, error)) (T, error) {
+	_, span := tracer.Start(ctx, "cache "+c.name, trace.WithAttributes(attribute.String("cache.key", key)))
+	defer span.End()
+	stats := requestStatsFrom(ctx)
+	if c == listCache {
+		// Every listCache load is a Kubernetes API call.
+		load = timedAPICall(stats, load)
+	}
+	if conf.CacheTTL <= 0 {
+		return shared(c, key, load)
+	}
+	if !noCache {
+		if value, ok := c.get(key); ok {
+			cacheRequestsTotal.WithLabelValues(c.name, "hit").Inc()
+			stats.recordCache(true)
+			span.SetAttributes(attribute.Bool("cache.hit", true))
+			return value.(T), nil
+		}
+	}
+	cacheRequestsTotal.WithLabelValues(c.name, "miss").Inc()
+	stats.recordCache(false)
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+	return shared(c, key, func() (T, error) {
+		value, err := load()
+		if err == nil {
+			c.set(key, value, conf.CacheTTL)
+		}
+		return value, err
+	})
+}
+
+func shared[T any](c *ttlCache, key string, load func() (T, error)) (T, error) {
+	value, err, _ := c.loads.Do(key, func() (any, error) {
+		return load()
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return value.(T), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNoCacheBypassesCaches(t *testing.T) {
+	setupTest(t, map[string]string{"APP_CACHE_TTL": "1m"}, testPod("web", true, "", 0))
+	if got := getMessage(t, "/pods"); got != "1/1" {
+		t.Fatalf("message = %q, want 1/1", got)
+	}
+	if _, err := k8sClient.CoreV1().Pods("default").Create(context.Background(), testPod("api", false, "", 0), v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct{ path, want string }{
+		{path: "/pods", want: "1/1"},
+		// Both the badge and the pod list are computed anew...
+		{path: "/pods?nocache=true", want: "1/2"},
+		// ...and replace the cached ones.
+		{path: "/pods", want: "1/2"},
+	} {
+		if got := getMessage(t, tt.path); got != tt.want {
+			t.Errorf("%s: message = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkCachedHit(b *testing.B) {
+	setupTest(b, map[string]string{"APP_CACHE_TTL": "1m"})
+	ctx := context.Background()
+	load := func() (int, error) { return 42, nil }
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := cached(ctx, badgeCache, "pods", false, load); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkTTLCacheSet(b *testing.B) {
+	c := newTTLCache("benchmark")
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("pods?namespace=ns-%d", i)
+	}
+	b.ReportAllocs()
+	for i := range b.N {
+		c.set(keys[i%len(keys)], i, time.Minute)
+	}
+}
+
+func TestTTLCacheSweep(t *testing.T) {
+	c := newTTLCache("test")
+	c.set("expired", 1, -time.Second)
+	c.set("live", 2, time.Minute)
+	c.sweep(time.Now())
+	if _, ok := c.entries["expired"]; ok {
+		t.Error("sweep kept the expired entry")
+	}
+	if value, ok := c.get("live"); !ok || value != 2 {
+		t.Errorf("get(live) = %v, %v after sweep", value, ok)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	registerEvaluator(funcEvaluator{
+		name:    "capacity",
+		enabled: func() bool { return conf.EnableCapacity },
+		badge:   capacityBadge,
+	})
+}
+
+// parseTolerations reads ?tolerations= as comma-separated key[=value][:effect]
+// entries; a key without a value tolerates the taint with any value.
+func parseTolerations(value string) ([]corev1.Toleration, error) {
+	var tolerations []corev1.Toleration
+	for _, entry := range splitList(value) {
+		rest, effect, _ := strings.Cut(entry, ":")
+		key, tolerationValue, hasValue := strings.Cut(rest, "=")
+		toleration := corev1.Toleration{Key: key, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffect(effect)}
+		if hasValue {
+			toleration.Operator = corev1.TolerationOpEqual
+			toleration.Value = tolerationValue
+		}
+		switch toleration.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid toleration: %s", entry))
+		}
+		if key == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid toleration: %s", entry))
+		}
+		tolerations = append(tolerations, toleration)
+	}
+	return tolerations, nil
+}
+
+// isSchedulable reports whether new pods with tolerations can land on node: it
+// is ready, uncordoned and every NoSchedule or NoExecute taint is tolerated.
+func isSchedulable(node *corev1.Node, tolerations []corev1.Toleration) bool {
+	if !isNodeReady(node) || node.Spec.Unschedulable {
+		return false
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for _, toleration := range tolerations {
+			if toleration.ToleratesTaint(&taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// podRequests returns the milli-requests of pod the scheduler accounts for: the
+// larger of the containers' sum and any init container, plus the pod overhead.
+func podRequests(pod *corev1.Pod) map[corev1.ResourceName]int64 {
+	totals := map[corev1.ResourceName]int64{}
+	for _, name := range usageResources {
+		var sum, initMax int64
+		for _, container := range pod.Spec.Containers {
+			quantity := container.Resources.Requests[name]
+			sum += quantity.MilliValue()
+		}
+		for _, container := range pod.Spec.InitContainers {
+			quantity := container.Resources.Requests[name]
+			initMax = max(initMax, quantity.MilliValue())
+		}
+		overhead := pod.Spec.Overhead[name]
+		totals[name] = max(sum, initMax) + overhead.MilliValue()
+	}
+	return totals
+}
+
+// evaluateCapacity sums the allocatable resources of the schedulable nodes
+// matching ?selector= and the requests of the pods not yet finished on them.
+func evaluateCapacity(ctx context.Context, params url.Values) (count healthCount, allocatable, requested map[corev1.ResourceName]int64, err error) {
+	tolerations, err := parseTolerations(params.Get("tolerations"))
+	if err != nil {
+		return healthCount{}, nil, nil, err
+	}
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, nil, nil, err
+	}
+	nodes, err := listNodes(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate, LabelSelector: q.LabelSelector})
+	if err != nil {
+		return healthCount{}, nil, nil, err
+	}
+	// Capacity is shared by every namespace, so pods are listed across all of
+	// them (or all of APP_NAMESPACES).
+	namespaces, _ := scopedNamespaces(nil)
+	pods, err := listPods(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate, Namespaces: namespaces})
+	if err != nil {
+		return healthCount{}, nil, nil, err
+	}
+	allocatable = map[corev1.ResourceName]int64{}
+	requested = map[corev1.ResourceName]int64{}
+	schedulable := map[string]bool{}
+	for _, node := range nodes {
+		ok := isSchedulable(node, tolerations)
+		count.add(node, ok)
+		if !ok {
+			continue
+		}
+		schedulable[node.Name] = true
+		for _, name := range usageResources {
+			quantity := node.Status.Allocatable[name]
+			allocatable[name] += quantity.MilliValue()
+		}
+	}
+	for _, pod := range pods {
+		if !schedulable[pod.Spec.NodeName] || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for name, value := range podRequests(pod) {
+			requested[name] += value
+		}
+	}
+	return count, allocatable, requested, nil
+}
+
+// capacityBadge reports the share of schedulable cpu and memory not yet
+// requested, e.g. "cpu 35% free, memory 20% free", or only ?resource=cpu|memory.
+// It is colored like the usage badges by the highest requested share.
+func capacityBadge(ctx context.Context, params url.Values) (badge, error) {
+	names := usageResources
+	if value := params.Get("resource"); value != "" {
+		name := corev1.ResourceName(value)
+		if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+			return badge{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown resource: %s", value))
+		}
+		names = []corev1.ResourceName{name}
+	}
+	count, allocatable, requested, err := evaluateCapacity(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := badge{Label: badgeLabel("capacity", params), Count: count}
+	if count.Healthy == 0 {
+		b.Message, b.Color = "no schedulable nodes", BADGE_COLOR_FATAL
+		return b, nil
+	}
+	var parts []string
+	highest := 0.0
+	for _, name := range names {
+		utilization := 1.0
+		if allocatable[name] > 0 {
+			utilization = float64(requested[name]) / float64(allocatable[name])
+		}
+		highest = max(highest, utilization)
+		parts = append(parts, fmt.Sprintf("%s %.0f%% free", name, max(0, 1-utilization)*100))
+	}
+	b.Message = strings.Join(parts, ", ")
+	b.Color = usageColor(highest)
+	return b, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "certificates",
+			enabled: func() bool { return conf.EnableCertificates },
+			badge:   certificatesBadge,
+		},
+		count: countCertificates,
+	})
+}
+
+var certificateGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+// customCondition returns the status of the condition with conditionType in
+// obj's status.conditions, or "" if it is absent.
+func customCondition(obj *unstructured.Unstructured, conditionType string) string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, condition := range conditions {
+		fields, ok := condition.(map[string]any)
+		if !ok || fields["type"] != conditionType {
+			continue
+		}
+		status, _ := fields["status"].(string)
+		return status
+	}
+	return ""
+}
+
+// evaluateCertificates counts Ready certificates and returns the earliest
+// status.notAfter among them (zero if none report one).
+func evaluateCertificates(ctx context.Context, params url.Values) (healthCount, time.Time, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, time.Time{}, err
+	}
+	certificates, err := listCustom(ctx, certificateGVR, q)
+	if err != nil {
+		return healthCount{}, time.Time{}, err
+	}
+	annotation := params.Get("annotation")
+	count := healthCount{}
+	var soonest time.Time
+	for _, certificate := range certificates {
+		if !matchAnnotation(certificate, annotation) {
+			continue
+		}
+		count.add(certificate, customCondition(certificate, "Ready") == "True")
+		notAfter, _, _ := unstructured.NestedString(certificate.Object, "status", "notAfter")
+		expiry, err := time.Parse(time.RFC3339, notAfter)
+		if err != nil {
+			continue
+		}
+		if soonest.IsZero() || expiry.Before(soonest) {
+			soonest = expiry
+		}
+	}
+	return count, soonest, nil
+}
+
+func countCertificates(ctx context.Context, params url.Values) (healthCount, error) {
+	count, _, err := evaluateCertificates(ctx, params)
+	return count, err
+}
+
+// certificatesBadge reports ready certificates and the soonest expiry, turning
+// yellow within APP_CERT_EXPIRY_WARNING of it and red once it has passed.
+func certificatesBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, soonest, err := evaluateCertificates(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := countBadge(badgeLabel("certificates", params), count, params)
+	if soonest.IsZero() {
+		return b, nil
+	}
+	remaining := time.Until(soonest)
+	switch {
+	case remaining <= 0:
+		b.Message += ", expired"
+		b.Color = BADGE_COLOR_FATAL
+	case remaining < conf.CertExpiryWarning:
+		b.Message += fmt.Sprintf(", expires in %s", formatDays(remaining))
+		if b.Color == BADGE_COLOR_HEALTHY {
+			b.Color = BADGE_COLOR_WARN
+		}
+	default:
+		b.Message += fmt.Sprintf(", expires in %s", formatDays(remaining))
+	}
+	return b, nil
+}
+
+func formatDays(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
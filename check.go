@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// resourceAccess is a resource a badge reads. Typed resources are also
+// watched when APP_USE_INFORMERS is set; informer marks them.
+type resourceAccess struct {
+	group    string
+	resource string
+	// cluster marks cluster-scoped resources, checked once instead of per
+	// namespace of APP_NAMESPACES.
+	cluster  bool
+	informer bool
+	// get is also needed by the single-object routes.
+	get bool
+}
+
+func (a resourceAccess) String() string {
+	if a.group == "" {
+		return a.resource
+	}
+	return a.resource + "." + a.group
+}
+
+var (
+	nodesAccess       = resourceAccess{resource: "nodes", cluster: true, informer: true, get: true}
+	podsAccess        = resourceAccess{resource: "pods", informer: true, get: true}
+	deploymentsAccess = resourceAccess{group: "apps", resource: "deployments", informer: true, get: true}
+)
+
+// evaluatorResources lists what each evaluator reads; cluster is composed of
+// the others and needs nothing of its own.
+var evaluatorResources = map[string][]resourceAccess{
+	"argocd/applications": {{group: argoApplicationGVR.Group, resource: argoApplicationGVR.Resource, get: true}},
+	"capacity":            {nodesAccess, podsAccess},
+	"certificates":        {{group: certificateGVR.Group, resource: certificateGVR.Resource}},
+	"cronjobs":            {{group: "batch", resource: "cronjobs", informer: true}, {group: "batch", resource: "jobs", informer: true}},
+	"daemonsets":          {{group: "apps", resource: "daemonsets", informer: true}},
+	"deployments":         {deploymentsAccess},
+	"events":              {{resource: "events", informer: true}},
+	"flux":                {{group: fluxGVRs[0].Group, resource: fluxGVRs[0].Resource}, {group: fluxGVRs[1].Group, resource: fluxGVRs[1].Resource}},
+	"helm":                {{resource: "secrets"}},
+	"hpas":                {{group: "autoscaling", resource: "horizontalpodautoscalers", informer: true}},
+	"imagepull":           {podsAccess},
+	"images":              {podsAccess},
+	"ingresses":           {{group: "networking.k8s.io", resource: "ingresses", informer: true}},
+	"jobs":                {{group: "batch", resource: "jobs", informer: true}},
+	"nodes":               {nodesAccess, podsAccess, {group: "policy", resource: "poddisruptionbudgets", informer: true}},
+	"pdb":                 {{group: "policy", resource: "poddisruptionbudgets", informer: true}},
+	"pdbs":                {{group: "policy", resource: "poddisruptionbudgets", informer: true}},
+	"pending":             {podsAccess},
+	"pods":                {podsAccess, nodesAccess, {resource: "persistentvolumeclaims", informer: true}},
+	"pvcs":                {{resource: "persistentvolumeclaims", informer: true}},
+	"quotas":              {{resource: "resourcequotas", informer: true}},
+	"restarts":            {podsAccess},
+	"rollouts":            {deploymentsAccess, {group: "apps", resource: "replicasets", informer: true}},
+	"services":            {{resource: "services", informer: true, get: true}, {group: "discovery.k8s.io", resource: "endpointslices", informer: true}},
+	"statefulsets":        {{group: "apps", resource: "statefulsets", informer: true}},
+	"topology":            {deploymentsAccess, podsAccess, nodesAccess},
+	"usage/nodes":         {nodesAccess, {group: nodeMetricsGVR.Group, resource: nodeMetricsGVR.Resource, cluster: true}},
+	"usage/pods":          {{group: podMetricsGVR.Group, resource: podMetricsGVR.Resource}},
+	"version":             {nodesAccess},
+}
+
+// accessCheck is one SelfSubjectAccessReview; label says who needs it.
+type accessCheck struct {
+	label      string
+	attributes authorizationv1.ResourceAttributes
+}
+
+func (a resourceAccess) checks(label string) []accessCheck {
+	verbs := []string{"list"}
+	if a.get {
+		verbs = append(verbs, "get")
+	}
+	if a.informer && conf.UseInformers {
+		verbs = append(verbs, "watch")
+	}
+	namespaces := []string{v1.NamespaceAll}
+	if !a.cluster && len(conf.Namespaces) > 0 {
+		namespaces = conf.Namespaces
+	}
+	var checks []accessCheck
+	for _, namespace := range namespaces {
+		for _, verb := range verbs {
+			checks = append(checks, accessCheck{label: label, attributes: authorizationv1.ResourceAttributes{
+				Namespace: namespace, Verb: verb, Group: a.group, Resource: a.resource,
+			}})
+		}
+	}
+	return checks
+}
+
+// serviceChecks collects what the service needs under its own identity: the
+// resources of the enabled evaluators and of the configured badges, the config
+// sources, the BadgeStatus objects and the leader election Lease.
+func serviceChecks(defs map[string]badgeDef) []accessCheck {
+	var checks []accessCheck
+	for _, e := range enabledEvaluators() {
+		for _, access := range evaluatorResources[e.Name()] {
+			checks = append(checks, access.checks(e.Name())...)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(defs)) {
+		def := defs[name]
+		if def.Impersonate != "" {
+			checks = append(checks, accessCheck{label: "badge/" + name, attributes: authorizationv1.ResourceAttributes{
+				Verb: "impersonate", Resource: "users", Name: def.Impersonate,
+			}})
+			continue
+		}
+		for _, access := range badgeDefResources(def) {
+			checks = append(checks, access.checks("badge/"+name)...)
+		}
+	}
+	if conf.ConfigMaps {
+		checks = append(checks, resourceAccess{resource: "configmaps", informer: true}.checks("APP_CONFIG_MAPS")...)
+	}
+	if conf.ConfigSecrets {
+		checks = append(checks, resourceAccess{resource: "secrets", informer: true}.checks("APP_CONFIG_SECRETS")...)
+	}
+	if conf.StatusNamespace != "" {
+		for _, verb := range []string{"get", "create", "update"} {
+			checks = append(checks, accessCheck{label: "APP_STATUS_NAMESPACE", attributes: authorizationv1.ResourceAttributes{
+				Namespace: conf.StatusNamespace, Verb: verb, Group: badgeStatusGVR.Group, Resource: badgeStatusGVR.Resource,
+			}})
+		}
+		checks = append(checks, accessCheck{label: "APP_STATUS_NAMESPACE", attributes: authorizationv1.ResourceAttributes{
+			Namespace: conf.StatusNamespace, Verb: "create", Resource: "events",
+		}})
+	}
+	if conf.LeaderElection {
+		for _, verb := range []string{"get", "create", "update"} {
+			checks = append(checks, accessCheck{label: "APP_LEADER_ELECTION", attributes: authorizationv1.ResourceAttributes{
+				Namespace: leaderElectionNamespace(), Verb: verb, Group: "coordination.k8s.io", Resource: "leases", Name: conf.LeaderElectionID,
+			}})
+		}
+	}
+	return checks
+}
+
+// badgeDefResources returns what a configured badge reads: the group and
+// resource params of an expression or custom badge, otherwise its resource's.
+func badgeDefResources(def badgeDef) []resourceAccess {
+	if def.Expression != "" || def.Resource == "custom" {
+		return []resourceAccess{{group: def.Params["group"], resource: def.Params["resource"]}}
+	}
+	return evaluatorResources[def.Resource]
+}
+
+// reviewAccess runs checks as the identity of client, printing one line per
+// check, and returns how many were denied or failed.
+func reviewAccess(ctx context.Context, w io.Writer, client kubernetes.Interface, checks []accessCheck) int {
+	denied := 0
+	seen := map[authorizationv1.ResourceAttributes]bool{}
+	for _, check := range checks {
+		if seen[check.attributes] {
+			continue
+		}
+		seen[check.attributes] = true
+		review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &check.attributes},
+		}, v1.CreateOptions{})
+		status := "ok"
+		switch {
+		case err != nil:
+			status = "error: " + err.Error()
+		case !review.Status.Allowed:
+			status = "MISSING"
+		}
+		if status != "ok" {
+			denied++
+		}
+		fmt.Fprintf(w, "%-8s %s %s (%s)\n", status, check.attributes.Verb, describeAttributes(check.attributes), check.label)
+	}
+	return denied
+}
+
+func describeAttributes(a authorizationv1.ResourceAttributes) string {
+	resource := resourceAccess{group: a.Group, resource: a.Resource}.String()
+	if a.Name != "" {
+		resource += "/" + a.Name
+	}
+	if a.Namespace == "" {
+		return resource
+	}
+	return resource + " in " + a.Namespace
+}
+
+// runCheck implements "k8s-status-badge check": it validates the badge config
+// and reviews, for every cluster, the permissions the enabled badges need,
+// then those of each impersonated badge as its user. It returns an error when
+// anything is invalid or missing, so it can gate a deployment.
+func runCheck(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: k8s-status-badge check")
+	}
+	problems := 0
+	defs, config := map[string]badgeDef{}, badgeConfig{}
+	if conf.ConfigFile != "" {
+		var err error
+		if defs, config, err = loadBadgeDefs(conf.ConfigFile); err != nil {
+			fmt.Fprintf(w, "config: %v\n", err)
+			problems++
+		} else {
+			fmt.Fprintf(w, "config: %s: %d badges\n", conf.ConfigFile, len(defs))
+		}
+	}
+
+	checks := serviceChecks(defs)
+	fmt.Fprintln(w, "\ncluster default:")
+	denied := reviewAccess(ctx, w, k8sClient, checks)
+	problems += denied
+	for _, cluster := range slices.Sorted(maps.Keys(clusters)) {
+		fmt.Fprintf(w, "\ncluster %s:\n", cluster)
+		problems += reviewAccess(ctx, w, clusters[cluster], checks)
+	}
+	for _, name := range slices.Sorted(maps.Keys(defs)) {
+		def := defs[name]
+		if def.Impersonate == "" {
+			continue
+		}
+		clients, err := impersonatingClients("", def.Impersonate)
+		if err != nil {
+			fmt.Fprintf(w, "\nbadge/%s as %s: %s\n", name, def.Impersonate, errorMessage(err))
+			problems++
+			continue
+		}
+		fmt.Fprintf(w, "\nbadge/%s as %s:\n", name, def.Impersonate)
+		var checks []accessCheck
+		for _, access := range badgeDefResources(def) {
+			checks = append(checks, access.checks("badge/"+name)...)
+		}
+		problems += reviewAccess(ctx, w, clients.typed, checks)
+	}
+
+	// The source informers would wait for their caches forever without
+	// permission to list them.
+	if (conf.ConfigMaps || conf.ConfigSecrets) && denied == 0 {
+		problems += checkConfigSources(ctx, w, defs, config)
+	}
+	if problems > 0 {
+		return fmt.Errorf("check failed: %d problems", problems)
+	}
+	return nil
+}
+
+// checkConfigSources reports the ConfigMaps and Secrets that would be skipped
+// when merged onto the APP_CONFIG badges.
+func checkConfigSources(ctx context.Context, w io.Writer, defs map[string]badgeDef, config badgeConfig) int {
+	fmt.Fprintln(w)
+	if err := startConfigSources(ctx, k8sClient); err != nil {
+		fmt.Fprintf(w, "config sources: %v\n", err)
+		return 1
+	}
+	_, _, skipped := mergeConfigSources(defs, config)
+	for _, err := range skipped {
+		fmt.Fprintf(w, "config source: %v\n", err)
+	}
+	fmt.Fprintf(w, "config sources: %d skipped\n", len(skipped))
+	return len(skipped)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync/atomic"
+)
+
+// clusterCheck is one input of the /cluster badge: either an enabled resource
+// or a named badge from the APP_CONFIG file.
+type clusterCheck struct {
+	Resource string `json:"resource"`
+	Badge    string `json:"badge"`
+	// Weight is the share of the check in the weighted healthy ratio; 1 by default.
+	Weight *float64 `json:"weight"`
+	// Required checks turn the badge red when they are fatal or fail to
+	// evaluate; optional ones turn it yellow at most.
+	Required bool `json:"required"`
+}
+
+// clusterChecks holds the checks from the APP_CONFIG file; none means defaultClusterChecks.
+var clusterChecks atomic.Pointer[[]clusterCheck]
+
+func init() {
+	clusterChecks.Store(&[]clusterCheck{})
+	registerEvaluator(funcEvaluator{
+		name:    "cluster",
+		enabled: func() bool { return conf.EnableCluster },
+		badge:   clusterBadge,
+	})
+}
+
+func defaultClusterChecks() []clusterCheck {
+	checks := []clusterCheck{}
+	for _, check := range []clusterCheck{{Resource: "nodes", Required: true}, {Resource: "pods"}, {Resource: "deployments"}} {
+		if _, ok := findEvaluator(check.Resource); ok {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+func (c clusterCheck) name() string {
+	if c.Badge != "" {
+		return "badge/" + c.Badge
+	}
+	return c.Resource
+}
+
+func (c clusterCheck) weight() float64 {
+	if c.Weight == nil {
+		return 1
+	}
+	return *c.Weight
+}
+
+func (c clusterCheck) validate(defs map[string]badgeDef) error {
+	if (c.Resource == "") == (c.Badge == "") {
+		return errors.New("exactly one of resource and badge must be set")
+	}
+	if c.weight() < 0 {
+		return errors.New("weight must not be negative")
+	}
+	if c.Badge != "" {
+		def, ok := defs[c.Badge]
+		if !ok {
+			return fmt.Errorf("unknown badge %q", c.Badge)
+		}
+		if def.Resource == "cluster" {
+			return errors.New("cannot include a cluster badge")
+		}
+		return nil
+	}
+	if c.Resource == "cluster" {
+		return errors.New("cannot include itself")
+	}
+	if _, ok := findEvaluator(c.Resource); !ok {
+		return fmt.Errorf("unknown or disabled resource %q", c.Resource)
+	}
+	return nil
+}
+
+// evaluate computes the badge behind the check, scoped to the cluster and
+// namespaces requested for the aggregate unless a named badge sets its own.
+func (c clusterCheck) evaluate(ctx context.Context, scope url.Values) (badge, error) {
+	params, compute, err := lookupBadge(c.name())
+	if err != nil {
+		return badge{}, err
+	}
+	for _, key := range []string{"cluster", "namespace", "nocache"} {
+		if value := scope.Get(key); value != "" && params.Get(key) == "" {
+			params.Set(key, value)
+		}
+	}
+	return computeBadge(ctx, c.name(), params, compute)
+}
+
+var levelColors = map[string]string{
+	BADGE_LEVEL_HEALTHY: BADGE_COLOR_HEALTHY,
+	BADGE_LEVEL_WARN:    BADGE_COLOR_WARN,
+	BADGE_LEVEL_FATAL:   BADGE_COLOR_FATAL,
+}
+
+var levelSeverity = map[string]int{
+	BADGE_LEVEL_HEALTHY: 0,
+	BADGE_LEVEL_WARN:    1,
+	BADGE_LEVEL_FATAL:   2,
+}
+
+// clusterBadge combines the configured checks into one badge colored after the
+// worst check, e.g. "2 issues". The badge also turns red once the weighted share
+// of healthy checks drops below the fatal threshold.
+func clusterBadge(ctx context.Context, params url.Values) (badge, error) {
+	checks := *clusterChecks.Load()
+	if len(checks) == 0 {
+		checks = defaultClusterChecks()
+	}
+	count := healthCount{}
+	level := BADGE_LEVEL_HEALTHY
+	var weightHealthy, weightTotal float64
+	for _, check := range checks {
+		checkLevel := BADGE_LEVEL_FATAL
+		if b, err := check.evaluate(ctx, params); err == nil && b.Level != "" && !b.Stale {
+			checkLevel = b.Level
+		}
+		if checkLevel == BADGE_LEVEL_FATAL && !check.Required {
+			checkLevel = BADGE_LEVEL_WARN
+		}
+		healthy := checkLevel == BADGE_LEVEL_HEALTHY
+		count.Total++
+		weightTotal += check.weight()
+		if healthy {
+			count.Healthy++
+			weightHealthy += check.weight()
+		}
+		count.Items = append(count.Items, itemStatus{Name: check.name(), Healthy: healthy})
+		if levelSeverity[checkLevel] > levelSeverity[level] {
+			level = checkLevel
+		}
+	}
+	if weightTotal > 0 && rateColor(weightHealthy/weightTotal, params) == BADGE_COLOR_FATAL {
+		level = BADGE_LEVEL_FATAL
+	}
+	message := "healthy"
+	switch issues := count.Total - count.Healthy; issues {
+	case 0:
+	case 1:
+		message = "1 issue"
+	default:
+		message = fmt.Sprintf("%d issues", issues)
+	}
+	return badge{
+		Label:   badgeLabel("cluster", params),
+		Message: message,
+		Color:   levelColors[level],
+		Count:   count,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// clusters holds the clients configured through APP_CLUSTERS, keyed by name.
+// Requests without ?cluster= keep using k8sClient and dynamicClient.
+var clusters = map[string]kubernetes.Interface{}
+var dynamicClusters = map[string]dynamic.Interface{}
+
+type clusterStatus struct {
+	Healthy   bool
+	LastError string
+	CheckedAt time.Time
+}
+
+var (
+	clusterStatusMu sync.Mutex
+	clusterStatuses = map[string]clusterStatus{}
+)
+
+// newClusters builds a client for each "name:kubeconfig[:context]" entry. A
+// kubeconfig user with "as" set impersonates that identity for the whole cluster.
+func newClusters(entries []string) (map[string]kubernetes.Interface, map[string]dynamic.Interface, error) {
+	typed := map[string]kubernetes.Interface{}
+	dynamics := map[string]dynamic.Interface{}
+	for _, entry := range entries {
+		name, rest, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || name == "" || rest == "" {
+			return nil, nil, fmt.Errorf("invalid cluster %q: want name:kubeconfig[:context]", entry)
+		}
+		path, context, _ := strings.Cut(rest, ":")
+		config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: path},
+			&clientcmd.ConfigOverrides{CurrentContext: context},
+		).ClientConfig()
+		if err != nil {
+			return nil, nil, fmt.Errorf("cluster %s: %w", name, err)
+		}
+		traceClient(config)
+		client, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cluster %s: %w", name, err)
+		}
+		dynamicClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cluster %s: %w", name, err)
+		}
+		typed[name] = client
+		dynamics[name] = dynamicClient
+		restConfigs[name] = config
+	}
+	return typed, dynamics, nil
+}
+
+func clusterClient(name string) kubernetes.Interface {
+	if name == "" {
+		return k8sClient
+	}
+	return clusters[name]
+}
+
+func clusterDynamicClient(name string) dynamic.Interface {
+	if name == "" {
+		return dynamicClient
+	}
+	return dynamicClusters[name]
+}
+
+func validateCluster(name string) error {
+	if name == "" || clusters[name] != nil {
+		return nil
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown cluster: %s", name))
+}
+
+// recordClusterCall tracks the outcome of the latest API call against a cluster.
+func recordClusterCall(name string, err error) {
+	status := clusterStatus{Healthy: err == nil, CheckedAt: time.Now()}
+	if err != nil {
+		status.LastError = errorMessage(err)
+	}
+	clusterStatusMu.Lock()
+	defer clusterStatusMu.Unlock()
+	clusterStatuses[name] = status
+}
+
+func handleClusters(ctx echo.Context) error {
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	clusterStatusMu.Lock()
+	defer clusterStatusMu.Unlock()
+	result := make([]echo.Map, 0, len(names))
+	for _, name := range names {
+		status, checked := clusterStatuses[name]
+		entry := echo.Map{"name": name, "checked": checked}
+		if checked {
+			entry["healthy"] = status.Healthy
+			entry["checkedAt"] = status.CheckedAt
+			if status.LastError != "" {
+				entry["lastError"] = status.LastError
+			}
+		}
+		result = append(result, entry)
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestClustersRoutes(t *testing.T) {
+	setupTest(t, map[string]string{"APP_ENV": "prod"}, testNode("n1", true))
+	broken := fake.NewSimpleClientset()
+	broken.PrependReactor("list", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New(`Get "https://10.0.0.1:6443/api/v1/nodes": x509: certificate signed by unknown authority`)
+	})
+	defer func(previous map[string]kubernetes.Interface) { clusters = previous }(clusters)
+	clusters = map[string]kubernetes.Interface{"edge": fake.NewSimpleClientset(testNode("n1", true)), "broken": broken}
+
+	if got := getMessage(t, "/clusters/edge/nodes"); got != "1/1 ready" {
+		t.Errorf("/clusters/edge/nodes: message = %q, want 1/1 ready", got)
+	}
+	var label struct {
+		Label string `json:"label"`
+	}
+	if err := json.Unmarshal(get(t, "/clusters/edge/nodes").Body.Bytes(), &label); err != nil || label.Label != "nodes(edge)" {
+		t.Errorf("/clusters/edge/nodes: label = %q, want nodes(edge)", label.Label)
+	}
+
+	get(t, "/clusters/broken/nodes")
+	body := get(t, "/clusters").Body.String()
+	if strings.Contains(body, "10.0.0.1") || strings.Contains(body, "x509") {
+		t.Errorf("/clusters exposes the API error: %s", body)
+	}
+	if !strings.Contains(body, `"lastError":"internal error"`) {
+		t.Errorf("/clusters = %s, want the sanitized lastError", body)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// configSourceFactories watch the ConfigMaps and Secrets carrying badge
+// definitions, one factory per namespace of APP_NAMESPACES or a single
+// cluster-wide one.
+var configSourceFactories []informers.SharedInformerFactory
+
+// configSourceChanges is signalled by the source informers; its buffer of one
+// coalesces the events of a chart install into a single reload.
+var configSourceChanges = make(chan struct{}, 1)
+
+func configSourcesStarted() bool {
+	return configSourceFactories != nil
+}
+
+// startConfigSources starts informers for the ConfigMaps (APP_CONFIG_MAPS) and
+// Secrets (APP_CONFIG_SECRETS) matching APP_CONFIG_SOURCE_SELECTOR and blocks
+// until they are synced.
+func startConfigSources(ctx context.Context, client kubernetes.Interface) error {
+	if _, err := labels.Parse(conf.ConfigSourceSelector); err != nil {
+		return fmt.Errorf("invalid APP_CONFIG_SOURCE_SELECTOR: %w", err)
+	}
+	options := []informers.SharedInformerOption{
+		informers.WithTweakListOptions(func(opts *v1.ListOptions) { opts.LabelSelector = conf.ConfigSourceSelector }),
+	}
+	namespaces := conf.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{v1.NamespaceAll}
+	}
+	var factories []informers.SharedInformerFactory
+	for _, namespace := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(client, conf.ResyncPeriod, append(options, informers.WithNamespace(namespace))...)
+		if conf.ConfigMaps {
+			onConfigSourceChange(factory.Core().V1().ConfigMaps().Informer())
+		}
+		if conf.ConfigSecrets {
+			onConfigSourceChange(factory.Core().V1().Secrets().Informer())
+		}
+		factories = append(factories, factory)
+	}
+	for _, factory := range factories {
+		factory.Start(ctx.Done())
+		for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+			if !synced {
+				return fmt.Errorf("failed to sync informer for %v", informerType)
+			}
+		}
+	}
+	configSourceFactories = factories
+	return nil
+}
+
+func onConfigSourceChange(informer cache.SharedIndexInformer) {
+	signal := func(any) {
+		select {
+		case configSourceChanges <- struct{}{}:
+		default:
+		}
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    signal,
+		UpdateFunc: func(_, obj any) { signal(obj) },
+		DeleteFunc: signal,
+	})
+}
+
+// watchConfigSources reloads the badge config after the source informers
+// report a change, once the burst of events has settled.
+func watchConfigSources(ctx context.Context, path string) {
+	// The initial adds were already merged by the first reload.
+	select {
+	case <-configSourceChanges:
+	default:
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-configSourceChanges:
+		}
+		time.Sleep(time.Second)
+		if err := reloadBadgeDefs(path); err != nil {
+			slog.Error("badge config reload failed", "path", path, "error", err.Error())
+		}
+	}
+}
+
+// configSource is one document of badge config, named "configmap/<ns>/<name>/<key>"
+// or "secret/<ns>/<name>/<key>" in errors.
+type configSource struct {
+	name string
+	data []byte
+}
+
+// listConfigSources returns every data key of the watched ConfigMaps and
+// Secrets, ordered by name so merges are reproducible.
+func listConfigSources() []configSource {
+	var sources []configSource
+	for _, factory := range configSourceFactories {
+		if conf.ConfigMaps {
+			configMaps, _ := factory.Core().V1().ConfigMaps().Lister().List(labels.Everything())
+			for _, configMap := range configMaps {
+				for key, value := range configMap.Data {
+					sources = append(sources, configSource{name: fmt.Sprintf("configmap/%s/%s/%s", configMap.Namespace, configMap.Name, key), data: []byte(value)})
+				}
+			}
+		}
+		if conf.ConfigSecrets {
+			secrets, _ := factory.Core().V1().Secrets().Lister().List(labels.Everything())
+			for _, secret := range secrets {
+				sources = append(sources, secretSources(secret)...)
+			}
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].name < sources[j].name })
+	return sources
+}
+
+func secretSources(secret *corev1.Secret) []configSource {
+	var sources []configSource
+	for key, value := range secret.Data {
+		sources = append(sources, configSource{name: fmt.Sprintf("secret/%s/%s/%s", secret.Namespace, secret.Name, key), data: value})
+	}
+	return sources
+}
+
+// checkSourceConfig rejects what only the operator may configure: the checks
+// behind /cluster and impersonation, which would let any namespace evaluate
+// badges with someone else's permissions.
+func checkSourceConfig(config badgeConfig) error {
+	if len(config.Cluster) > 0 {
+		return fmt.Errorf("cluster checks are only read from APP_CONFIG")
+	}
+	for _, def := range config.Badges {
+		if def.Impersonate != "" {
+			return fmt.Errorf("badge %s: impersonate is only read from APP_CONFIG", def.Name)
+		}
+	}
+	return nil
+}
+
+// mergeConfigSources adds each source to the validated base config in turn.
+// A source that fails to parse or conflicts with what was merged before it,
+// such as a duplicate badge name, is skipped with an error so one team's
+// ConfigMap cannot take down the badges of the others. The errors of the
+// skipped sources are returned for the check command.
+func mergeConfigSources(defs map[string]badgeDef, base badgeConfig) (map[string]badgeDef, badgeConfig, []error) {
+	merged := 0
+	var skipped []error
+	for _, source := range listConfigSources() {
+		config, err := parseBadgeConfig(source.name, source.data)
+		if err == nil {
+			if err = checkSourceConfig(config); err != nil {
+				err = fmt.Errorf("%s: %w", source.name, err)
+			}
+		}
+		if err != nil {
+			slog.Error("badge config source skipped", "source", source.name, "error", err.Error())
+			skipped = append(skipped, err)
+			continue
+		}
+		candidate := badgeConfig{
+			Badges:      append(append([]badgeDef{}, base.Badges...), config.Badges...),
+			Cluster:     base.Cluster,
+			Probes:      append(append([]probeDef{}, base.Probes...), config.Probes...),
+			Maintenance: append(append([]maintenanceWindow{}, base.Maintenance...), config.Maintenance...),
+			Critical:    append(append([]criticalRule{}, base.Critical...), config.Critical...),
+			Profiles:    append(append([]healthProfile{}, base.Profiles...), config.Profiles...),
+		}
+		candidateDefs, candidate, err := validateBadgeConfig(source.name, candidate)
+		if err != nil {
+			slog.Error("badge config source skipped", "source", source.name, "error", err.Error())
+			skipped = append(skipped, err)
+			continue
+		}
+		defs, base = candidateDefs, candidate
+		merged++
+	}
+	slog.Debug("badge config sources merged", "sources", merged)
+	return defs, base, skipped
+}
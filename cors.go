@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// badgeHeaders are the response headers browser clients may read besides the
+// CORS-safelisted ones.
+var badgeHeaders = []string{
+	"X-Badge-Healthy",
+	"X-Badge-Total",
+	"X-Badge-Stale",
+	"X-Badge-Error-Status",
+	echo.HeaderXRequestID,
+	"ETag",
+}
+
+// corsMiddleware lets pages served from origins fetch badges and read their
+// headers. It runs before authentication so preflight requests need no token.
+func corsMiddleware(origins []string) echo.MiddlewareFunc {
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:  origins,
+		AllowMethods:  []string{http.MethodGet, http.MethodHead, http.MethodPost},
+		AllowHeaders:  []string{echo.HeaderAuthorization, echo.HeaderContentType, "If-None-Match"},
+		ExposeHeaders: badgeHeaders,
+		MaxAge:        3600,
+	})
+}
+
+// embeddable allows badges to be embedded by cross-origin pages that require
+// Cross-Origin-Resource-Policy, e.g. under Cross-Origin-Embedder-Policy.
+func embeddable(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		ctx.Response().Header().Set("Cross-Origin-Resource-Policy", "cross-origin")
+		ctx.Response().Header().Set(echo.HeaderXContentTypeOptions, "nosniff")
+		return next(ctx)
+	}
+}
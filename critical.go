@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// criticalRule marks the objects in a namespace and/or matching a label
+// selector as critical: a single unhealthy one turns aggregate badges red
+// whatever the healthy ratio.
+type criticalRule struct {
+	Namespace string `json:"namespace"`
+	Selector  string `json:"selector"`
+
+	selector labels.Selector
+}
+
+func (r *criticalRule) parse() error {
+	if r.Namespace == "" && r.Selector == "" {
+		return fmt.Errorf("namespace or selector is required")
+	}
+	selector, err := labels.Parse(r.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector %q: %w", r.Selector, err)
+	}
+	r.selector = selector
+	return nil
+}
+
+func (r criticalRule) matches(obj v1.Object) bool {
+	if r.Namespace != "" && obj.GetNamespace() != r.Namespace {
+		return false
+	}
+	return r.selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// criticalRules is swapped together with badgeDefs on reload.
+var criticalRules atomic.Pointer[[]criticalRule]
+
+func init() {
+	criticalRules.Store(&[]criticalRule{})
+}
+
+func isCritical(obj v1.Object) bool {
+	for _, rule := range *criticalRules.Load() {
+		if rule.matches(obj) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "cronjobs",
+			enabled: func() bool { return conf.EnableCronJobs },
+			badge:   cronJobsBadge,
+		},
+		count: countCronJobs,
+	})
+}
+
+// cronJobOwner returns the name of the CronJob that created job, if any.
+func cronJobOwner(job *batchv1.Job) string {
+	for _, owner := range job.OwnerReferences {
+		if owner.Kind == "CronJob" {
+			return owner.Name
+		}
+	}
+	return ""
+}
+
+// isCronJobOverdue reports whether the CronJob has gone longer than one
+// schedule interval plus APP_CRONJOB_GRACE without a successful run.
+func isCronJobOverdue(cronJob *batchv1.CronJob, lastSuccess time.Time, now time.Time) bool {
+	spec := cronJob.Spec.Schedule
+	if cronJob.Spec.TimeZone != nil {
+		spec = "CRON_TZ=" + *cronJob.Spec.TimeZone + " " + spec
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return false
+	}
+	next := schedule.Next(now)
+	interval := schedule.Next(next).Sub(next)
+	since := lastSuccess
+	if since.IsZero() {
+		since = cronJob.CreationTimestamp.Time
+	}
+	return now.Sub(since) > interval+conf.CronJobGrace
+}
+
+// evaluateCronJobs counts unsuspended CronJobs whose most recent finished Job
+// succeeded as healthy; overdue ones count as unhealthy regardless.
+func evaluateCronJobs(ctx context.Context, params url.Values) (count healthCount, overdue int, err error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, 0, err
+	}
+	cronJobs, err := listCronJobs(ctx, q)
+	if err != nil {
+		return healthCount{}, 0, err
+	}
+	jobs, err := listJobs(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate, Namespaces: q.Namespaces})
+	if err != nil {
+		return healthCount{}, 0, err
+	}
+	latest := map[string]*batchv1.Job{}
+	lastSuccess := map[string]time.Time{}
+	for _, job := range jobs {
+		owner := cronJobOwner(job)
+		if owner == "" {
+			continue
+		}
+		key := job.Namespace + "/" + owner
+		if complete := jobCondition(job, batchv1.JobComplete); complete != nil && complete.LastTransitionTime.After(lastSuccess[key]) {
+			lastSuccess[key] = complete.LastTransitionTime.Time
+		}
+		if jobCondition(job, batchv1.JobComplete) == nil && jobCondition(job, batchv1.JobFailed) == nil {
+			continue
+		}
+		if previous := latest[key]; previous == nil || job.CreationTimestamp.After(previous.CreationTimestamp.Time) {
+			latest[key] = job
+		}
+	}
+	now := time.Now()
+	annotation := params.Get("annotation")
+	for _, cronJob := range cronJobs {
+		if !matchAnnotation(cronJob, annotation) || (cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend) {
+			continue
+		}
+		key := cronJob.Namespace + "/" + cronJob.Name
+		success := lastSuccess[key]
+		if cronJob.Status.LastSuccessfulTime != nil && cronJob.Status.LastSuccessfulTime.After(success) {
+			success = cronJob.Status.LastSuccessfulTime.Time
+		}
+		if isCronJobOverdue(cronJob, success, now) {
+			overdue++
+			count.add(cronJob, false)
+			continue
+		}
+		job := latest[key]
+		count.add(cronJob, job == nil || jobCondition(job, batchv1.JobComplete) != nil)
+	}
+	return count, overdue, nil
+}
+
+func countCronJobs(ctx context.Context, params url.Values) (healthCount, error) {
+	count, _, err := evaluateCronJobs(ctx, params)
+	return count, err
+}
+
+func cronJobsBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, overdue, err := evaluateCronJobs(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := countBadge(badgeLabel("cronjobs", params), count, params)
+	if overdue > 0 {
+		b.Message += fmt.Sprintf(", %d overdue", overdue)
+		b.Color = BADGE_COLOR_FATAL
+	}
+	return b, nil
+}
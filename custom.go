@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	registerEvaluator(funcEvaluator{
+		name:    "custom",
+		enabled: func() bool { return conf.EnableCustom },
+		badge:   customBadge,
+	})
+}
+
+// fieldCondition is a parsed healthyWhen expression: a dotted field path, an
+// optional operator ("=" or "!=") and the value to compare against. Without an
+// operator the field only has to be present and non-empty.
+type fieldCondition struct {
+	Path     []string
+	Operator string
+	Value    string
+}
+
+// parseFieldCondition parses expressions such as ".status.health.status=Healthy"
+// or ".status.conditions[type=Ready].status!=False".
+func parseFieldCondition(expr string) (fieldCondition, error) {
+	expr = strings.TrimSpace(expr)
+	path, operator, value := expr, "", ""
+	// Split on the first operator outside a [key=value] list selector.
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '!', '=':
+			if depth != 0 || operator != "" {
+				continue
+			}
+			if expr[i] == '!' && strings.HasPrefix(expr[i:], "!=") {
+				path, operator, value = expr[:i], "!=", expr[i+2:]
+			} else if expr[i] == '=' {
+				path, operator, value = expr[:i], "=", expr[i+1:]
+			}
+		}
+	}
+	segments := strings.Split(strings.TrimPrefix(path, "."), ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return fieldCondition{}, fmt.Errorf("invalid field path: %s", path)
+		}
+	}
+	return fieldCondition{Path: segments, Operator: operator, Value: value}, nil
+}
+
+// lookup resolves path against obj. A segment of the form name[key=value]
+// selects the first element of the list name whose key field equals value.
+func lookup(obj any, path []string) (any, bool) {
+	current := obj
+	for _, segment := range path {
+		name, selector, hasSelector := strings.Cut(segment, "[")
+		fields, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = fields[name]; !ok {
+			return nil, false
+		}
+		if !hasSelector {
+			continue
+		}
+		key, value, _ := strings.Cut(strings.TrimSuffix(selector, "]"), "=")
+		items, ok := current.([]any)
+		if !ok {
+			return nil, false
+		}
+		current = nil
+		for _, item := range items {
+			if fields, ok := item.(map[string]any); ok && fmt.Sprint(fields[key]) == value {
+				current = item
+				break
+			}
+		}
+		if current == nil {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func (c fieldCondition) matches(obj *unstructured.Unstructured) bool {
+	value, found := lookup(obj.Object, c.Path)
+	actual := ""
+	if found && value != nil {
+		actual = fmt.Sprint(value)
+	}
+	switch c.Operator {
+	case "=":
+		return found && actual == c.Value
+	case "!=":
+		return actual != c.Value
+	default:
+		return actual != "" && actual != "false"
+	}
+}
+
+// customGVR reads the group, version and resource parameters. Core resources
+// are refused so the endpoint cannot be used to probe Secrets and the like.
+func customGVR(params url.Values) (schema.GroupVersionResource, error) {
+	gvr := schema.GroupVersionResource{
+		Group:    params.Get("group"),
+		Version:  params.Get("version"),
+		Resource: params.Get("resource"),
+	}
+	if gvr.Group == "" || gvr.Version == "" || gvr.Resource == "" {
+		return schema.GroupVersionResource{}, echo.NewHTTPError(http.StatusBadRequest, "group, version and resource are required")
+	}
+	return gvr, nil
+}
+
+func countCustom(ctx context.Context, params url.Values) (healthCount, error) {
+	gvr, err := customGVR(params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	condition, err := parseFieldCondition(params.Get("healthyWhen"))
+	if err != nil || params.Get("healthyWhen") == "" {
+		return healthCount{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid healthyWhen: %s", params.Get("healthyWhen")))
+	}
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	objects, err := listCustom(ctx, gvr, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	annotation := params.Get("annotation")
+	count := healthCount{}
+	for _, obj := range objects {
+		if !matchAnnotation(obj, annotation) {
+			continue
+		}
+		count.add(obj, condition.matches(obj))
+	}
+	return count, nil
+}
+
+func customBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countCustom(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	return countBadge(badgeLabel(params.Get("resource"), params), count, params), nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"net/url"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "daemonsets",
+			enabled: func() bool { return conf.EnableDaemonSets },
+			badge:   daemonSetsBadge,
+		},
+		count: countDaemonSets,
+	})
+}
+
+func countDaemonSets(ctx context.Context, params url.Values) (healthCount, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	daemonSets, err := listDaemonSets(ctx, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	annotation := params.Get("annotation")
+	count := healthCount{}
+	for _, daemonSet := range daemonSets {
+		if !matchAnnotation(daemonSet, annotation) {
+			continue
+		}
+		count.add(daemonSet, daemonSet.Status.NumberReady >= daemonSet.Status.DesiredNumberScheduled)
+	}
+	return count, nil
+}
+
+func daemonSetsBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countDaemonSets(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	return countBadge(badgeLabel("daemonsets", params), count, params), nil
+}
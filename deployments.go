@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "deployments",
+			enabled: func() bool { return conf.EnableDeployments },
+			badge:   deploymentsBadge,
+			routes: map[string]echo.HandlerFunc{
+				"/deployments/:namespace/:name": handleDeployment,
+			},
+		},
+		count: countDeployments,
+	})
+}
+
+func countDeployments(ctx context.Context, params url.Values) (healthCount, error) {
+	mode := params.Get("mode")
+	switch mode {
+	case "", "minready", "replicas":
+	default:
+		return healthCount{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown mode: %s", mode))
+	}
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	deployments, err := listDeployments(ctx, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	annotation := params.Get("annotation")
+	count := healthCount{}
+	for _, deployment := range deployments {
+		if !matchAnnotation(deployment, annotation) {
+			continue
+		}
+		desired := int32(1)
+		if deployment.Spec.Replicas != nil {
+			desired = *deployment.Spec.Replicas
+		}
+		if mode == "replicas" {
+			// Count replicas instead of deployments; surge pods must not push the ratio above 1.
+			count.Total += int(desired)
+			count.Healthy += int(min(deployment.Status.ReadyReplicas, desired))
+			count.addItem(deployment, deployment.Status.ReadyReplicas >= desired)
+			continue
+		}
+		// availableReplicas only counts pods that stayed ready for minReadySeconds,
+		// so churning pods never catch up with the desired count.
+		replicas := deployment.Status.ReadyReplicas
+		if mode == "minready" {
+			replicas = deployment.Status.AvailableReplicas
+		}
+		count.add(deployment, replicas >= desired)
+	}
+	return count, nil
+}
+
+func deploymentsBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countDeployments(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	return countBadge(badgeLabel("deployments", params), count, params), nil
+}
+
+// isRolloutStuck reports whether the deployment controller gave up on the
+// current rollout after progressDeadlineSeconds.
+func isRolloutStuck(deployment *appsv1.Deployment) bool {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing {
+			return condition.Status == corev1.ConditionFalse && condition.Reason == "ProgressDeadlineExceeded"
+		}
+	}
+	return false
+}
+
+// deploymentBadge reports the ready replicas of a single deployment.
+func deploymentBadge(namespace, name string) badgeFunc {
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		q, err := newListQuery(ctx, params)
+		if err != nil {
+			return badge{}, err
+		}
+		deployment, err := getDeployment(ctx, q, namespace, name)
+		if apierrors.IsNotFound(err) {
+			return badge{}, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("deployment %s/%s not found", namespace, name))
+		}
+		recordClusterCall(q.Cluster, err)
+		if err != nil {
+			kubernetesErrorsTotal.WithLabelValues("deployments", q.Cluster).Inc()
+			return badge{}, err
+		}
+		desired := int32(1)
+		if deployment.Spec.Replicas != nil {
+			desired = *deployment.Spec.Replicas
+		}
+		count := healthCount{Healthy: int(min(deployment.Status.ReadyReplicas, desired)), Total: int(desired)}
+		b := badge{
+			Label:   name,
+			Message: fmt.Sprintf("%d/%d ready", deployment.Status.ReadyReplicas, desired),
+			Color:   count.color(params),
+			Count:   count,
+		}
+		if isRolloutStuck(deployment) {
+			b.Message += ", rollout stuck"
+			b.Color = BADGE_COLOR_FATAL
+		}
+		return b, nil
+	}
+}
+
+func handleDeployment(ctx echo.Context) error {
+	namespace, name := ctx.Param("namespace"), ctx.Param("name")
+	return serveBadge(ctx, "deployments/"+namespace+"/"+name, ctx.QueryParams(), deploymentBadge(namespace, name))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testDeployment(name string, replicas, ready, available int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas, MinReadySeconds: 30},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: ready, AvailableReplicas: available},
+	}
+}
+
+func TestCountDeploymentsMinReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		mode       string
+		healthy    int
+	}{
+		{name: "available", deployment: testDeployment("api", 2, 2, 2), mode: "minready", healthy: 1},
+		{name: "ready but not yet available", deployment: testDeployment("api", 2, 2, 1), mode: "minready", healthy: 0},
+		{name: "ready without minready", deployment: testDeployment("api", 2, 2, 1), mode: "", healthy: 1},
+		{name: "not ready", deployment: testDeployment("api", 2, 1, 1), mode: "", healthy: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, nil, tt.deployment)
+			count, err := countDeployments(context.Background(), url.Values{"mode": {tt.mode}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count.Healthy != tt.healthy || count.Total != 1 {
+				t.Errorf("count = %d/%d, want %d/1", count.Healthy, count.Total, tt.healthy)
+			}
+		})
+	}
+}
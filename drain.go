@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// drainBlocker returns why draining would get stuck on pod, or "" when it
+// would be evicted or skipped. It follows kubectl drain without --force or
+// --delete-emptydir-data: DaemonSet and mirror pods are skipped, while pods
+// without a controller, with emptyDir volumes or whose PDB allows no
+// disruption block the drain.
+func drainBlocker(pod *corev1.Pod, pdbs []drainPDB) string {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return ""
+	}
+	if _, mirror := pod.Annotations[corev1.MirrorPodAnnotationKey]; mirror {
+		return ""
+	}
+	controller := v1.GetControllerOf(pod)
+	if controller != nil && controller.Kind == "DaemonSet" {
+		return ""
+	}
+	if controller == nil {
+		return "unmanaged"
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil {
+			return "local storage"
+		}
+	}
+	for _, pdb := range pdbs {
+		if pdb.namespace == pod.Namespace && pdb.disruptionsAllowed == 0 && pdb.selector.Matches(labels.Set(pod.Labels)) {
+			return "pdb"
+		}
+	}
+	return ""
+}
+
+type drainPDB struct {
+	namespace          string
+	selector           labels.Selector
+	disruptionsAllowed int32
+}
+
+// drainSafetyBadge reports whether the node could be drained right now, green
+// "safe to drain" or red with the pods that would block it by reason, for
+// linking from maintenance runbooks.
+func drainSafetyBadge(name string) badgeFunc {
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		q, err := newListQuery(ctx, params)
+		if err != nil {
+			return badge{}, err
+		}
+		_, err = getNode(ctx, q, name)
+		if apierrors.IsNotFound(err) {
+			return badge{}, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("node %s not found", name))
+		}
+		recordClusterCall(q.Cluster, err)
+		if err != nil {
+			kubernetesErrorsTotal.WithLabelValues("nodes", q.Cluster).Inc()
+			return badge{}, err
+		}
+		scope := listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate}
+		pods, err := listPods(ctx, scope)
+		if err != nil {
+			return badge{}, err
+		}
+		list, err := listPDBs(ctx, scope)
+		if err != nil {
+			return badge{}, err
+		}
+		pdbs := make([]drainPDB, 0, len(list))
+		for _, pdb := range list {
+			selector, err := v1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil {
+				continue
+			}
+			pdbs = append(pdbs, drainPDB{namespace: pdb.Namespace, selector: selector, disruptionsAllowed: pdb.Status.DisruptionsAllowed})
+		}
+
+		count := healthCount{}
+		reasons := map[string]int{}
+		for _, pod := range pods {
+			if pod.Spec.NodeName != name {
+				continue
+			}
+			reason := drainBlocker(pod, pdbs)
+			count.add(pod, reason == "")
+			if reason != "" {
+				reasons[reason]++
+			}
+		}
+		b := badge{Label: name, Message: "safe to drain", Color: BADGE_COLOR_HEALTHY, Count: count}
+		if blocking := count.Total - count.Healthy; blocking > 0 {
+			var details []string
+			for _, reason := range []string{"pdb", "local storage", "unmanaged"} {
+				if reasons[reason] > 0 {
+					details = append(details, fmt.Sprintf("%d %s", reasons[reason], reason))
+				}
+			}
+			b.Message = fmt.Sprintf("%d blocking: %s", blocking, strings.Join(details, ", "))
+			b.Color = BADGE_COLOR_FATAL
+		}
+		return b, nil
+	}
+}
+
+func handleDrainSafety(ctx echo.Context) error {
+	name := ctx.Param("node")
+	return serveBadge(ctx, "drain-safety/"+name, ctx.QueryParams(), drainSafetyBadge(name))
+}
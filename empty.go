@@ -0,0 +1,51 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	EMPTY_STATE_UNKNOWN = "unknown"
+	EMPTY_STATE_HEALTHY = "healthy"
+	EMPTY_STATE_ERROR   = "error"
+)
+
+// parseEmptyState reads how a badge counting no objects is shown from the
+// empty param, falling back to APP_EMPTY_STATE.
+func parseEmptyState(params url.Values) (string, error) {
+	state := cmp.Or(params.Get("empty"), conf.EmptyState)
+	switch state {
+	case EMPTY_STATE_UNKNOWN, EMPTY_STATE_HEALTHY, EMPTY_STATE_ERROR:
+		return state, nil
+	}
+	return "", echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid empty: %s", state))
+}
+
+// applyEmptyState replaces the "0/0" of a badge that counted no objects, e.g.
+// with a grey "no pods". The default message names the kind of the label.
+func applyEmptyState(b badge, params url.Values) badge {
+	if !b.Empty {
+		return b
+	}
+	state, err := parseEmptyState(params)
+	if err != nil {
+		state = EMPTY_STATE_UNKNOWN
+	}
+	kind, _, _ := strings.Cut(b.Label, "(")
+	b.Message = cmp.Or(params.Get("emptyMessage"), conf.EmptyMessage, "no "+kind)
+	switch state {
+	case EMPTY_STATE_HEALTHY:
+		b.Color = BADGE_COLOR_HEALTHY
+	case EMPTY_STATE_ERROR:
+		b.Color = BADGE_COLOR_FATAL
+	default:
+		b.Color = BADGE_COLOR_ERROR
+	}
+	return b
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+)
+
+type badgeFunc func(context.Context, url.Values) (badge, error)
+
+// Evaluator computes one type of badge, served at /{Name()}. Each badge type
+// adds itself to the registry from an init function in its own file, so adding
+// one touches no shared table.
+type Evaluator interface {
+	Name() string
+	// Enabled reports whether the badge is turned on by the configuration.
+	Enabled() bool
+	Evaluate(ctx context.Context, params url.Values) (badge, error)
+}
+
+// Counter is implemented by evaluators whose badge is a plain healthy/total
+// object count; readiness and the self-test use it.
+type Counter interface {
+	Count(ctx context.Context, params url.Values) (healthCount, error)
+}
+
+// Router is implemented by evaluators that serve further badge routes, e.g.
+// for a single object.
+type Router interface {
+	Routes() map[string]echo.HandlerFunc
+}
+
+var evaluators = map[string]Evaluator{}
+
+// registerEvaluator adds e to the registry. It panics on duplicate names since
+// registration happens from init functions.
+func registerEvaluator(e Evaluator) {
+	if _, ok := evaluators[e.Name()]; ok {
+		panic(fmt.Sprintf("evaluator %s registered twice", e.Name()))
+	}
+	evaluators[e.Name()] = e
+}
+
+// funcEvaluator adapts a badge function to Evaluator.
+type funcEvaluator struct {
+	name    string
+	enabled func() bool
+	badge   badgeFunc
+	routes  map[string]echo.HandlerFunc
+}
+
+func (e funcEvaluator) Name() string  { return e.name }
+func (e funcEvaluator) Enabled() bool { return e.enabled() }
+func (e funcEvaluator) Evaluate(ctx context.Context, params url.Values) (badge, error) {
+	return e.badge(ctx, params)
+}
+func (e funcEvaluator) Routes() map[string]echo.HandlerFunc { return e.routes }
+
+// countingEvaluator is a funcEvaluator with a count function.
+type countingEvaluator struct {
+	funcEvaluator
+	count func(context.Context, url.Values) (healthCount, error)
+}
+
+func (e countingEvaluator) Count(ctx context.Context, params url.Values) (healthCount, error) {
+	return e.count(ctx, params)
+}
+
+// enabledEvaluators returns the enabled evaluators sorted by name.
+func enabledEvaluators() []Evaluator {
+	var enabled []Evaluator
+	for _, e := range evaluators {
+		if e.Enabled() {
+			enabled = append(enabled, e)
+		}
+	}
+	sort.Slice(enabled, func(i, j int) bool { return enabled[i].Name() < enabled[j].Name() })
+	return enabled
+}
+
+func findEvaluator(name string) (Evaluator, bool) {
+	e, ok := evaluators[name]
+	if !ok || !e.Enabled() {
+		return nil, false
+	}
+	return e, true
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDisabledEvaluatorRoutes(t *testing.T) {
+	setupTest(t, map[string]string{"APP_ENABLE_NODES": "false"}, testPod("web", true, "", 0), testNode("n1", true))
+	for _, tt := range []struct {
+		path string
+		code int
+	}{
+		{path: "/nodes", code: http.StatusNotFound},
+		{path: "/nodes/n1", code: http.StatusNotFound},
+		{path: "/pods", code: http.StatusOK},
+	} {
+		if rec := get(t, tt.path); rec.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	registerEvaluator(funcEvaluator{
+		name:    "events",
+		enabled: func() bool { return conf.EnableWarningEvents },
+		badge:   eventsBadge,
+	})
+}
+
+// eventTime returns when event last occurred, falling back through the
+// timestamps older and newer event producers fill in.
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.FirstTimestamp.Time
+	}
+}
+
+// countWarningEvents counts Warning events seen within ?window= (APP_WARNING_EVENTS_WINDOW),
+// optionally only those about objects of ?kind=.
+func countWarningEvents(ctx context.Context, params url.Values) (int, error) {
+	window := conf.WarningEventsWindow
+	if value := params.Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid window: %s", value))
+		}
+		window = parsed
+	}
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+	q = q.withFieldSelector("type=" + corev1.EventTypeWarning)
+	if kind := params.Get("kind"); kind != "" {
+		if strings.ContainsAny(kind, ",=!") {
+			return 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid kind: %s", kind))
+		}
+		q = q.withFieldSelector("involvedObject.kind=" + kind)
+	}
+	events, err := listEvents(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	since := time.Now().Add(-window)
+	warnings := 0
+	for _, event := range events {
+		if eventTime(event).After(since) {
+			warnings++
+		}
+	}
+	return warnings, nil
+}
+
+func eventsBadge(ctx context.Context, params url.Values) (badge, error) {
+	warnings, err := countWarningEvents(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	color := BADGE_COLOR_HEALTHY
+	if warnings >= conf.WarningEventsFatal {
+		color = BADGE_COLOR_FATAL
+	} else if warnings >= conf.WarningEventsWarn {
+		color = BADGE_COLOR_WARN
+	}
+	return badge{
+		Label:   badgeLabel("events", params),
+		Message: fmt.Sprintf("%d warnings", warnings),
+		Color:   color,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/cel-go/cel"
+	"github.com/labstack/echo/v4"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// expressionCostLimit bounds the work of a single evaluation so a careless
+// expression over large lists cannot stall the badge.
+const expressionCostLimit = 1_000_000
+
+// compileExpression compiles a CEL health expression evaluated against each
+// listed object, bound to the variable object, e.g.
+// "object.status.phase in ['Running', 'Succeeded']".
+func compileExpression(expr string) (cel.Program, error) {
+	env, err := cel.NewEnv(cel.Variable("object", cel.MapType(cel.StringType, cel.DynType)))
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expr)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("expression must return a bool, not %s", ast.OutputType())
+	}
+	return env.Program(ast, cel.CostLimit(expressionCostLimit))
+}
+
+// evalExpression reports whether obj satisfies program. Evaluation errors, such
+// as a missing map key, count as unhealthy.
+func evalExpression(program cel.Program, obj *unstructured.Unstructured) bool {
+	out, _, err := program.Eval(map[string]any{"object": obj.Object})
+	if err != nil {
+		return false
+	}
+	healthy, ok := out.Value().(bool)
+	return ok && healthy
+}
+
+// expressionBadge counts the objects of the definition's group, version and
+// resource params that satisfy its expression. Definitions are trusted, so
+// unlike /custom they may name core resources by leaving the group empty.
+func expressionBadge(def badgeDef) badgeFunc {
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		gvr := schema.GroupVersionResource{
+			Group:    params.Get("group"),
+			Version:  params.Get("version"),
+			Resource: params.Get("resource"),
+		}
+		if gvr.Version == "" || gvr.Resource == "" {
+			return badge{}, echo.NewHTTPError(http.StatusBadRequest, "version and resource are required")
+		}
+		q, err := newListQuery(ctx, params)
+		if err != nil {
+			return badge{}, err
+		}
+		objects, err := listCustom(ctx, gvr, q)
+		if err != nil {
+			return badge{}, err
+		}
+		annotation := params.Get("annotation")
+		count := healthCount{}
+		for _, obj := range objects {
+			if !matchAnnotation(obj, annotation) {
+				continue
+			}
+			count.add(obj, evalExpression(def.program, obj))
+		}
+		return countBadge(badgeLabel(gvr.Resource, params), count, params), nil
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed favicon.ico
+var favicon []byte
+
+func handleFavicon(ctx echo.Context) error {
+	ctx.Response().Header().Set("Cache-Control", "public, max-age=86400")
+	return ctx.Blob(http.StatusOK, "image/x-icon", favicon)
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFavicon(t *testing.T) {
+	setupTest(t, nil)
+	rec := get(t, "/favicon.ico")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "image/") {
+		t.Errorf("Content-Type = %q, want an image", contentType)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), favicon) || len(favicon) == 0 {
+		t.Errorf("body is not the embedded favicon")
+	}
+}
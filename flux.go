@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "flux",
+			enabled: func() bool { return conf.EnableFlux },
+			badge:   fluxBadge,
+		},
+		count: countFlux,
+	})
+}
+
+var fluxGVRs = []schema.GroupVersionResource{
+	{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Resource: "kustomizations"},
+	{Group: "helm.toolkit.fluxcd.io", Version: "v2", Resource: "helmreleases"},
+}
+
+// evaluateFlux counts Ready Kustomizations and HelmReleases together with those
+// whose reconciliation is failing. A kind whose CRD is not installed is
+// skipped; the badge is only unavailable when neither is.
+func evaluateFlux(ctx context.Context, params url.Values) (count healthCount, failing int, err error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, 0, err
+	}
+	annotation := params.Get("annotation")
+	missing := 0
+	for _, gvr := range fluxGVRs {
+		objects, err := listCustom(ctx, gvr, q)
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Code == http.StatusNotFound {
+			missing++
+			continue
+		}
+		if err != nil {
+			return healthCount{}, 0, err
+		}
+		for _, obj := range objects {
+			if !matchAnnotation(obj, annotation) {
+				continue
+			}
+			ready := customCondition(obj, "Ready")
+			count.add(obj, ready == "True")
+			if ready == "False" {
+				failing++
+			}
+		}
+	}
+	if missing == len(fluxGVRs) {
+		return healthCount{}, 0, echo.NewHTTPError(http.StatusNotFound, "flux not available")
+	}
+	return count, failing, nil
+}
+
+func countFlux(ctx context.Context, params url.Values) (healthCount, error) {
+	count, _, err := evaluateFlux(ctx, params)
+	return count, err
+}
+
+// fluxBadge reports Ready/total and is red while any reconciliation is failing;
+// objects that are still progressing only lower the healthy ratio.
+func fluxBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, failing, err := evaluateFlux(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := countBadge(badgeLabel("flux", params), count, params)
+	if failing > 0 {
+		b.Color = BADGE_COLOR_FATAL
+	}
+	return b, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"cmp"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+)
+
+// badgeURL returns the absolute URL of the current request with format set,
+// signed again when the request was signed so the URL stays valid.
+func badgeURL(ctx echo.Context, format string) string {
+	req := ctx.Request()
+	query := url.Values{}
+	for key, values := range req.URL.Query() {
+		query[key] = values
+	}
+	query.Set("format", format)
+	if query.Get("sig") != "" && conf.SigningSecret != "" {
+		query.Set("sig", signature(conf.SigningSecret, req.URL.Path, query))
+	}
+	u := url.URL{Scheme: ctx.Scheme(), Host: req.Host, Path: req.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// gitlabJSON describes b in the shape of GitLab's project badge API, so it can
+// be registered with POST /projects/:id/badges. The image is the SVG rendering
+// of the same badge and the link defaults to its raw JSON unless ?link= or
+// APP_BADGE_LINK_URL is set. Gitea and GitLab READMEs embed ?format=svg directly.
+func gitlabJSON(ctx echo.Context, b badge) echo.Map {
+	image := badgeURL(ctx, "svg")
+	link := cmp.Or(ctx.QueryParam("link"), conf.BadgeLinkURL, badgeURL(ctx, "json"))
+	return echo.Map{
+		"name":               cmp.Or(b.Name, b.Label),
+		"link_url":           link,
+		"image_url":          image,
+		"rendered_link_url":  link,
+		"rendered_image_url": image,
+	}
+}
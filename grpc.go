@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"k8s-status-badge/badgepb"
+)
+
+// badgeService implements badgepb.BadgeServiceServer on top of computeBadge.
+type badgeService struct {
+	badgepb.UnimplementedBadgeServiceServer
+}
+
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:      codes.InvalidArgument,
+	http.StatusUnauthorized:    codes.Unauthenticated,
+	http.StatusForbidden:       codes.PermissionDenied,
+	http.StatusNotFound:        codes.NotFound,
+	http.StatusTooManyRequests: codes.ResourceExhausted,
+}
+
+// grpcError maps err like respondError does, keeping the sanitized message.
+func grpcError(err error) error {
+	code := codes.Unavailable
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		if mapped, ok := grpcCodes[httpErr.Code]; ok {
+			code = mapped
+		}
+	}
+	return status.Error(code, errorMessage(err))
+}
+
+func badgeMessage(b badge) *badgepb.Badge {
+	message := &badgepb.Badge{
+		Name:    b.Name,
+		Label:   b.Label,
+		Message: b.Message,
+		Color:   b.Color,
+		Level:   b.Level,
+		Healthy: int32(b.Count.Healthy),
+		Total:   int32(b.Count.Total),
+		Stale:   b.Stale,
+	}
+	for _, item := range b.Count.Items {
+		message.Items = append(message.Items, &badgepb.Item{Namespace: item.Namespace, Name: item.Name, Healthy: item.Healthy})
+	}
+	return message
+}
+
+// requestBadge computes name with the request parameters layered over those of
+// a named badge, which they cannot override.
+func requestBadge(ctx context.Context, name string, requestParams map[string]string) (badge, error) {
+	defParams, compute, err := lookupBadge(name)
+	if err != nil {
+		return badge{}, err
+	}
+	params := url.Values{}
+	for key, value := range requestParams {
+		params.Set(key, value)
+	}
+	for key, values := range defParams {
+		params[key] = values
+	}
+	return computeBadge(ctx, name, params, compute)
+}
+
+func (badgeService) GetBadge(ctx context.Context, req *badgepb.GetBadgeRequest) (*badgepb.Badge, error) {
+	b, err := requestBadge(ctx, req.GetName(), req.GetParams())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return badgeMessage(b), nil
+}
+
+func (badgeService) ListBadges(ctx context.Context, _ *badgepb.ListBadgesRequest) (*badgepb.ListBadgesResponse, error) {
+	res := &badgepb.ListBadgesResponse{}
+	for _, l := range listedBadges() {
+		b, err := computeBadge(ctx, l.name, l.params, l.compute)
+		if err != nil {
+			res.Badges = append(res.Badges, &badgepb.Badge{Name: l.name, Error: errorMessage(err)})
+			continue
+		}
+		res.Badges = append(res.Badges, badgeMessage(b))
+	}
+	return res, nil
+}
+
+// WatchBadge evaluates the badge every interval and sends it when its level,
+// color or message changed. Failed evaluations end the stream unless a badge
+// has already been sent, in which case the next evaluation is awaited.
+func (badgeService) WatchBadge(req *badgepb.WatchBadgeRequest, stream badgepb.BadgeService_WatchBadgeServer) error {
+	interval := conf.EventsInterval
+	if req.GetIntervalSeconds() > 0 {
+		interval = time.Duration(req.GetIntervalSeconds()) * time.Second
+	}
+	ctx := stream.Context()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last *badgepb.Badge
+	for {
+		b, err := requestBadge(ctx, req.GetName(), req.GetParams())
+		switch {
+		case err != nil && last == nil:
+			return grpcError(err)
+		case err == nil:
+			message := badgeMessage(b)
+			if last == nil || message.Level != last.Level || message.Color != last.Color || message.Message != last.Message {
+				if err := stream.Send(message); err != nil {
+					return err
+				}
+				last = message
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// grpcToken reads the bearer token from the authorization metadata.
+func grpcToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(value, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// grpcAuthorize requires one of APP_AUTH_TOKENS when tokens or
+// APP_SIGNING_SECRET are configured. RPCs carry no signed URL, so with signing
+// alone they would otherwise evaluate any params.
+func grpcAuthorize(ctx context.Context) error {
+	if validToken(grpcToken(ctx), conf.AuthTokens) || (len(conf.AuthTokens) == 0 && conf.SigningSecret == "") {
+		return nil
+	}
+	return status.Error(codes.Unauthenticated, "unauthorized")
+}
+
+// recoverRPC turns a panic in an RPC into an Internal error like
+// middleware.Recover does for HTTP requests.
+func recoverRPC(method string, err *error) {
+	if r := recover(); r != nil {
+		slog.Error("grpc handler panicked", "method", method, "panic", fmt.Sprint(r))
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+func newGRPCServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+			defer recoverRPC(info.FullMethod, &err)
+			if err := grpcAuthorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+			defer recoverRPC(info.FullMethod, &err)
+			if err := grpcAuthorize(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	)
+	badgepb.RegisterBadgeServiceServer(server, badgeService{})
+	return server
+}
+
+// serveGRPC serves the BadgeService on addr until ctx is done. Open WatchBadge
+// streams get the same APP_SHUTDOWN_TIMEOUT to finish as HTTP requests on
+// shutdown.
+func serveGRPC(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := newGRPCServer()
+	go func() {
+		<-ctx.Done()
+		timer := time.AfterFunc(conf.ShutdownTimeout, server.Stop)
+		defer timer.Stop()
+		server.GracefulStop()
+	}()
+	return server.Serve(listener)
+}
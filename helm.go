@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "helm",
+			enabled: func() bool { return conf.EnableHelm },
+			badge:   helmBadge,
+		},
+		count: countHelmReleases,
+	})
+}
+
+const HELM_RELEASE_SECRET_TYPE = "helm.sh/release.v1"
+
+// helmRelease holds the fields of a decoded Helm v3 release that the badge needs.
+type helmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+}
+
+// decodeHelmRelease decodes the "release" key of a release Secret: base64 text
+// of the JSON release, gzipped unless it was written by an old Helm version.
+func decodeHelmRelease(secret *corev1.Secret) (helmRelease, error) {
+	data, err := base64.StdEncoding.DecodeString(string(secret.Data["release"]))
+	if err != nil {
+		return helmRelease{}, err
+	}
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return helmRelease{}, err
+		}
+		defer reader.Close()
+		if data, err = io.ReadAll(reader); err != nil {
+			return helmRelease{}, err
+		}
+	}
+	var release helmRelease
+	if err := json.Unmarshal(data, &release); err != nil {
+		return helmRelease{}, err
+	}
+	return release, nil
+}
+
+// evaluateHelmReleases counts the latest revision of every release as healthy
+// when it is deployed, along with the failed and pending-* ones. Uninstalled
+// releases whose history was kept are skipped.
+func evaluateHelmReleases(ctx context.Context, params url.Values) (count healthCount, failed, pending int, err error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, 0, 0, err
+	}
+	releases, err := listHelmReleases(ctx, q)
+	if err != nil {
+		return healthCount{}, 0, 0, err
+	}
+	annotation := params.Get("annotation")
+	var keys []string
+	latest := map[string]helmRelease{}
+	owners := map[string]*v1.ObjectMeta{}
+	for _, r := range releases {
+		if !matchAnnotation(&r.meta, annotation) {
+			continue
+		}
+		key := r.meta.Namespace + "/" + r.release.Name
+		current, seen := latest[key]
+		if !seen {
+			keys = append(keys, key)
+		}
+		if !seen || r.release.Version > current.Version {
+			latest[key] = r.release
+			owners[key] = &r.meta
+		}
+	}
+	for _, key := range keys {
+		release := latest[key]
+		status := release.Info.Status
+		if status == "uninstalled" {
+			continue
+		}
+		count.add(owners[key], status == "deployed")
+		switch {
+		case status == "failed":
+			failed++
+		case strings.HasPrefix(status, "pending-"):
+			pending++
+		}
+	}
+	return count, failed, pending, nil
+}
+
+func countHelmReleases(ctx context.Context, params url.Values) (healthCount, error) {
+	count, _, _, err := evaluateHelmReleases(ctx, params)
+	return count, err
+}
+
+// helmBadge reports deployed releases, red when any failed and yellow while
+// any is pending.
+func helmBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, failed, pending, err := evaluateHelmReleases(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := countBadge(badgeLabel("helm", params), count, params)
+	b.Message = countMessage(count, "deployed")
+	switch {
+	case failed > 0:
+		b.Color = BADGE_COLOR_FATAL
+	case pending > 0:
+		b.Color = BADGE_COLOR_WARN
+	}
+	return b, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func testReleaseSecret(namespace, name string, version int, status string) *corev1.Secret {
+	var data bytes.Buffer
+	writer := gzip.NewWriter(&data)
+	fmt.Fprintf(writer, `{"name": %q, "version": %d, "info": {"status": %q}, "chart": {"values": {"password": "hunter2"}}}`, name, version, status)
+	writer.Close()
+	return &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: fmt.Sprintf("sh.helm.release.v1.%s.v%d", name, version), Namespace: namespace},
+		Type:       HELM_RELEASE_SECRET_TYPE,
+		Data:       map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(data.Bytes()))},
+	}
+}
+
+func TestHelmBadge(t *testing.T) {
+	setupTest(t, map[string]string{"APP_ENABLE_HELM": "true", "APP_CACHE_TTL": "1m"},
+		testReleaseSecret("default", "web", 1, "failed"),
+		testReleaseSecret("default", "web", 2, "deployed"),
+		testReleaseSecret("prod", "api", 1, "failed"),
+	)
+	defer criticalRules.Store(criticalRules.Load())
+	criticalRules.Store(&[]criticalRule{{Namespace: "prod", selector: labels.Everything()}})
+
+	b, err := helmBadge(context.Background(), url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Message != "1/2 deployed, 1 critical" || b.Color != BADGE_COLOR_FATAL {
+		t.Errorf("badge = %s %s, want 1/2 deployed, 1 critical %s", b.Message, b.Color, BADGE_COLOR_FATAL)
+	}
+	for key, entry := range listCache.entries {
+		if _, ok := entry.value.([]*corev1.Secret); ok {
+			t.Errorf("listCache holds the Secrets of %s", key)
+		}
+	}
+}
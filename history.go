@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const BADGE_LEVEL_ERROR = "error"
+
+// historyPoint is one evaluation of a listed badge.
+type historyPoint struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Healthy int       `json:"healthy"`
+	Total   int       `json:"total"`
+}
+
+// historyBackend holds the recorded points; main replaces it with the store
+// configured by APP_HISTORY_DATABASE.
+var historyBackend historyStore = newMemoryStore("")
+
+// watchHistory evaluates the listed badges every interval, recording failed
+// evaluations as errors so outages count against the uptime, and drops points
+// older than retention. With a shared store only the leader evaluates.
+func watchHistory(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if !historyBackend.leaderOnly() || leading.Load() {
+			recordHistory(ctx, retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func recordHistory(ctx context.Context, retention time.Duration) {
+	now := time.Now()
+	for _, l := range listedBadges() {
+		point := historyPoint{Time: now, Level: BADGE_LEVEL_ERROR}
+		if b, err := computeBadge(ctx, l.name, l.params, l.compute); err == nil {
+			point.Healthy, point.Total = b.Count.Healthy, b.Count.Total
+			if b.Level != "" && !b.Stale {
+				point.Level = b.Level
+			}
+		}
+		if err := historyBackend.record(ctx, l.name, point); err != nil {
+			slog.Error("history record failed", "badge", l.name, "error", err.Error())
+		}
+	}
+	if retention > 0 {
+		if err := historyBackend.prune(ctx, now.Add(-retention)); err != nil {
+			slog.Error("history prune failed", "error", err.Error())
+		}
+	}
+	if err := historyBackend.flush(); err != nil {
+		slog.Error("history save failed", "error", err.Error())
+	}
+}
+
+func parseWindow(params url.Values) (string, time.Duration, error) {
+	window := params.Get("window")
+	if window == "" {
+		window = "24h"
+	}
+	duration, err := time.ParseDuration(window)
+	if err != nil || duration <= 0 {
+		return "", 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid window: %s", window))
+	}
+	return window, duration, nil
+}
+
+// historyBadge reports the share of evaluations of name within ?window= that
+// were healthy, e.g. "99.2% last 24h".
+func historyBadge(name string) badgeFunc {
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		window, duration, err := parseWindow(params)
+		if err != nil {
+			return badge{}, err
+		}
+		label := badgeLabel(strings.TrimPrefix(name, "badge/")+" uptime", params)
+		points, err := historyBackend.since(ctx, name, time.Now().Add(-duration))
+		if err != nil {
+			return badge{}, err
+		}
+		if len(points) == 0 {
+			return badge{Label: label, Message: "no data", Color: BADGE_COLOR_ERROR}, nil
+		}
+		// Maintenance counts neither for nor against the uptime.
+		count := healthCount{}
+		for _, point := range points {
+			switch point.Level {
+			case BADGE_LEVEL_MAINTENANCE:
+				continue
+			case BADGE_LEVEL_HEALTHY:
+				count.Healthy++
+			}
+			count.Total++
+		}
+		return badge{
+			Label:   label,
+			Message: fmt.Sprintf("%.1f%% last %s", count.rate()*100, window),
+			Color:   count.color(params),
+			Count:   count,
+		}, nil
+	}
+}
+
+func historyHandler(name string) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		return serveBadge(ctx, name+"/history", ctx.QueryParams(), historyBadge(name))
+	}
+}
+
+func handleNamedHistory(ctx echo.Context) error {
+	name := ctx.Param("name")
+	if _, ok := (*badgeDefs.Load())[name]; !ok {
+		return respondError(ctx, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unknown badge: %s", name)))
+	}
+	return historyHandler("badge/" + name)(ctx)
+}
+
+// handleHistoryJSON returns the raw points within ?window=, for every badge or
+// only ?badge=.
+func handleHistoryJSON(ctx echo.Context) error {
+	_, duration, err := parseWindow(ctx.QueryParams())
+	if err != nil {
+		return respondError(ctx, err)
+	}
+	since := time.Now().Add(-duration)
+	result := map[string][]historyPoint{}
+	names := []string{ctx.QueryParam("badge")}
+	if names[0] == "" {
+		names = names[:0]
+		for _, l := range listedBadges() {
+			names = append(names, l.name)
+		}
+	}
+	for _, name := range names {
+		points, err := historyBackend.since(ctx.Request().Context(), name, since)
+		if err != nil {
+			return respondError(ctx, err)
+		}
+		result[name] = points
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// historyStore keeps the history points of the listed badges.
+type historyStore interface {
+	record(ctx context.Context, name string, point historyPoint) error
+	// since returns the points of name recorded after since, oldest first.
+	since(ctx context.Context, name string, since time.Time) ([]historyPoint, error)
+	// prune drops the points recorded before cutoff.
+	prune(ctx context.Context, cutoff time.Time) error
+	// flush persists points the store only keeps in memory.
+	flush() error
+	// leaderOnly reports whether only the leader records, as every replica
+	// shares the store.
+	leaderOnly() bool
+	close() error
+}
+
+// openHistoryStore opens database, a "sqlite://<path>" or "postgres://" URL,
+// and migrates its schema. Without a database the history is kept in memory
+// and saved to file when set.
+func openHistoryStore(ctx context.Context, database, file string) (historyStore, error) {
+	if database == "" {
+		store := newMemoryStore(file)
+		if err := store.load(); err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+	var store *sqlStore
+	switch {
+	case strings.HasPrefix(database, "sqlite://"):
+		db, err := sql.Open("sqlite", strings.TrimPrefix(database, "sqlite://"))
+		if err != nil {
+			return nil, err
+		}
+		// SQLite serialises writers; a single connection avoids SQLITE_BUSY.
+		db.SetMaxOpenConns(1)
+		store = &sqlStore{db: db, placeholder: func(int) string { return "?" }}
+	case strings.HasPrefix(database, "postgres://"), strings.HasPrefix(database, "postgresql://"):
+		db, err := sql.Open("pgx", database)
+		if err != nil {
+			return nil, err
+		}
+		store = &sqlStore{db: db, placeholder: func(i int) string { return fmt.Sprintf("$%d", i) }, shared: true}
+	default:
+		return nil, fmt.Errorf("unsupported history database: %s", strings.SplitN(database, "://", 2)[0])
+	}
+	if err := store.migrate(ctx); err != nil {
+		store.close()
+		return nil, fmt.Errorf("history migration failed: %w", err)
+	}
+	return store, nil
+}
+
+// memoryStore keeps the last APP_HISTORY_SIZE points of each badge, oldest
+// first, optionally saved to a JSON file.
+type memoryStore struct {
+	mu     sync.Mutex
+	points map[string][]historyPoint
+	file   string
+}
+
+func newMemoryStore(file string) *memoryStore {
+	return &memoryStore{points: map[string][]historyPoint{}, file: file}
+}
+
+func (s *memoryStore) record(_ context.Context, name string, point historyPoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	points := append(s.points[name], point)
+	if len(points) > conf.HistorySize {
+		points = points[len(points)-conf.HistorySize:]
+	}
+	s.points[name] = points
+	return nil
+}
+
+func (s *memoryStore) since(_ context.Context, name string, since time.Time) ([]historyPoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	points := []historyPoint{}
+	for _, point := range s.points[name] {
+		if point.Time.After(since) {
+			points = append(points, point)
+		}
+	}
+	return points, nil
+}
+
+func (s *memoryStore) prune(_ context.Context, cutoff time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, points := range s.points {
+		i := 0
+		for i < len(points) && points[i].Time.Before(cutoff) {
+			i++
+		}
+		if i == len(points) {
+			delete(s.points, name)
+		} else {
+			s.points[name] = points[i:]
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) load() error {
+	if s.file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.Unmarshal(data, &s.points)
+}
+
+// flush writes the history to the file through a temporary file so a crash
+// never leaves it truncated.
+func (s *memoryStore) flush() error {
+	if s.file == "" {
+		return nil
+	}
+	s.mu.Lock()
+	data, err := json.Marshal(s.points)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := s.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file)
+}
+
+// leaderOnly is false: each replica serves the history it recorded itself.
+func (s *memoryStore) leaderOnly() bool {
+	return false
+}
+
+func (s *memoryStore) close() error {
+	return s.flush()
+}
+
+// historyMigrations are applied in order and recorded in schema_migrations;
+// append new statements, never edit applied ones. Times are Unix milliseconds
+// so both dialects store them the same way.
+var historyMigrations = []string{
+	`CREATE TABLE history (
+		badge TEXT NOT NULL,
+		time BIGINT NOT NULL,
+		level TEXT NOT NULL,
+		healthy INTEGER NOT NULL,
+		total INTEGER NOT NULL
+	)`,
+	`CREATE INDEX history_badge_time ON history (badge, time)`,
+	`CREATE INDEX history_time ON history (time)`,
+}
+
+// sqlStore keeps the history in SQLite or Postgres.
+type sqlStore struct {
+	db          *sql.DB
+	placeholder func(i int) string
+	// shared stores are written by every replica, so only the leader records.
+	shared bool
+}
+
+// HISTORY_MIGRATION_LOCK is the Postgres advisory lock key serialising the
+// migrations of replicas starting together.
+const HISTORY_MIGRATION_LOCK = 0x6b736268 // "ksbh"
+
+func (s *sqlStore) migrate(ctx context.Context) error {
+	// The advisory lock belongs to the session, so everything runs on one
+	// connection.
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if s.shared {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, HISTORY_MIGRATION_LOCK); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, HISTORY_MIGRATION_LOCK)
+	}
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+	var applied int
+	if err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		return err
+	}
+	for version := applied; version < len(historyMigrations); version++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, historyMigrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (`+s.placeholder(1)+`)`, version+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqlStore) record(ctx context.Context, name string, point historyPoint) error {
+	_, err := s.db.ExecContext(ctx,
+		fmt.Sprintf(`INSERT INTO history (badge, time, level, healthy, total) VALUES (%s, %s, %s, %s, %s)`,
+			s.placeholder(1), s.placeholder(2), s.placeholder(3), s.placeholder(4), s.placeholder(5)),
+		name, point.Time.UnixMilli(), point.Level, point.Healthy, point.Total)
+	return err
+}
+
+func (s *sqlStore) since(ctx context.Context, name string, since time.Time) ([]historyPoint, error) {
+	rows, err := s.db.QueryContext(ctx,
+		fmt.Sprintf(`SELECT time, level, healthy, total FROM history WHERE badge = %s AND time > %s ORDER BY time`,
+			s.placeholder(1), s.placeholder(2)),
+		name, since.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	points := []historyPoint{}
+	for rows.Next() {
+		var point historyPoint
+		var millis int64
+		if err := rows.Scan(&millis, &point.Level, &point.Healthy, &point.Total); err != nil {
+			return nil, err
+		}
+		point.Time = time.UnixMilli(millis)
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+func (s *sqlStore) prune(ctx context.Context, cutoff time.Time) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM history WHERE time < `+s.placeholder(1), cutoff.UnixMilli())
+	return err
+}
+
+func (s *sqlStore) flush() error {
+	return nil
+}
+
+func (s *sqlStore) leaderOnly() bool {
+	return s.shared
+}
+
+func (s *sqlStore) close() error {
+	return s.db.Close()
+}
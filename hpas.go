@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "hpas",
+			enabled: func() bool { return conf.EnableHPAs },
+			badge:   hpasBadge,
+		},
+		count: countHPAs,
+	})
+}
+
+// isHPASaturated reports whether the autoscaler is pinned at maxReplicas or
+// reports its desired scale as limited.
+func isHPASaturated(hpa *autoscalingv2.HorizontalPodAutoscaler) bool {
+	if hpa.Status.CurrentReplicas >= hpa.Spec.MaxReplicas {
+		return true
+	}
+	for _, condition := range hpa.Status.Conditions {
+		if condition.Type == autoscalingv2.ScalingLimited && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func countHPAs(ctx context.Context, params url.Values) (healthCount, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	hpas, err := listHPAs(ctx, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	annotation := params.Get("annotation")
+	count := healthCount{}
+	for _, hpa := range hpas {
+		if !matchAnnotation(hpa, annotation) {
+			continue
+		}
+		count.add(hpa, !isHPASaturated(hpa))
+	}
+	return count, nil
+}
+
+func hpasBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countHPAs(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := countBadge(badgeLabel("hpas", params), count, params)
+	if saturated := count.Total - count.Healthy; saturated > 0 {
+		b.Message += fmt.Sprintf(", %d at max", saturated)
+		if b.Color == BADGE_COLOR_HEALTHY {
+			b.Color = BADGE_COLOR_WARN
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	registerEvaluator(funcEvaluator{
+		name:    "imagepull",
+		enabled: func() bool { return conf.EnableImagePull },
+		badge:   imagePullBadge,
+	})
+}
+
+// imagePullRegistries returns the registry hosts of the containers of pod
+// failing to pull their image.
+func imagePullRegistries(pod *corev1.Pod) []string {
+	var registries []string
+	for _, status := range containerStatuses(pod) {
+		if status.State.Waiting == nil {
+			continue
+		}
+		switch status.State.Waiting.Reason {
+		case "ImagePullBackOff", "ErrImagePull":
+			registry, _ := imageRegistry(status.Image)
+			if !slices.Contains(registries, registry) {
+				registries = append(registries, registry)
+			}
+		}
+	}
+	return registries
+}
+
+// countImagePullFailures counts pods without image pull failures as healthy,
+// grouping the failing ones by registry host so an outage names its registry.
+func countImagePullFailures(ctx context.Context, params url.Values) (healthCount, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	pods, err := listPods(ctx, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	count := healthCount{Groups: map[string]int{}}
+	for _, pod := range filterPods(pods, params) {
+		registries := imagePullRegistries(pod)
+		count.add(pod, len(registries) == 0)
+		for _, registry := range registries {
+			count.Groups[registry]++
+		}
+	}
+	return count, nil
+}
+
+func imagePullBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countImagePullFailures(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	failures := count.Total - count.Healthy
+	color := BADGE_COLOR_HEALTHY
+	if failures > conf.ImagePullThreshold {
+		color = BADGE_COLOR_FATAL
+	} else if failures > 0 {
+		color = BADGE_COLOR_WARN
+	}
+	message := fmt.Sprintf("%d failing", failures)
+	if registry := mostCommon(count.Groups); registry != "" {
+		message += fmt.Sprintf(", %d from %s", count.Groups[registry], registry)
+	}
+	return badge{
+		Label:   badgeLabel("imagepull", params),
+		Message: message,
+		Color:   color,
+		Count:   count,
+	}, nil
+}
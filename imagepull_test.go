@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestImagePullRegistries(t *testing.T) {
+	tests := []struct {
+		name    string
+		waiting string
+		want    []string
+	}{
+		{name: "ErrImagePull", waiting: "ErrImagePull", want: []string{"ghcr.io"}},
+		{name: "ImagePullBackOff", waiting: "ImagePullBackOff", want: []string{"ghcr.io"}},
+		{name: "CrashLoopBackOff", waiting: "CrashLoopBackOff", want: nil},
+		{name: "running", waiting: "", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := testPod("web", false, tt.waiting, 0)
+			pod.Status.ContainerStatuses[0].Image = "ghcr.io/piny940/web:v1"
+			if got := imagePullRegistries(pod); !slices.Equal(got, tt.want) {
+				t.Errorf("imagePullRegistries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImagePullBadge(t *testing.T) {
+	pulling := testPod("web", false, "ErrImagePull", 0)
+	pulling.Status.ContainerStatuses[0].Image = "nginx:1.27"
+	pulling.Status.ContainerStatuses = append(pulling.Status.ContainerStatuses, corev1.ContainerStatus{
+		Name:  "sidecar",
+		Image: "ghcr.io/piny940/sidecar:v1",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+	})
+	crashing := testPod("api", false, "CrashLoopBackOff", 3)
+	setupTest(t, nil, pulling, crashing, testPod("db", true, "", 0))
+
+	b, err := imagePullBadge(context.Background(), url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Count.Healthy != 2 || b.Count.Total != 3 {
+		t.Errorf("count = %d/%d, want 2/3", b.Count.Healthy, b.Count.Total)
+	}
+	if b.Count.Groups["docker.io"] != 1 || b.Count.Groups["ghcr.io"] != 1 {
+		t.Errorf("groups = %v, want docker.io and ghcr.io once", b.Count.Groups)
+	}
+	if b.Color != BADGE_COLOR_FATAL {
+		t.Errorf("color = %s, want %s", b.Color, BADGE_COLOR_FATAL)
+	}
+}
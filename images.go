@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "images",
+			enabled: func() bool { return conf.EnableImages },
+			badge:   imagesBadge,
+		},
+		count: countImages,
+	})
+}
+
+// imageRegistry splits the registry host off image, which is docker.io for
+// images without one.
+func imageRegistry(image string) (registry, name string) {
+	if first, rest, ok := strings.Cut(image, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first, rest
+	}
+	return "docker.io", image
+}
+
+// imageViolations lists the image policies broken by image: a missing or
+// :latest tag, a missing digest, or a registry outside APP_IMAGE_ALLOWED_REGISTRIES.
+func imageViolations(image string) []string {
+	name, digest, _ := strings.Cut(image, "@")
+	registry, name := imageRegistry(name)
+	tag := ""
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		tag = name[i+1:]
+	}
+
+	var violations []string
+	if conf.ImageDisallowLatest && digest == "" && (tag == "" || tag == "latest") {
+		violations = append(violations, "latest tag")
+	}
+	if conf.ImageRequireDigest && digest == "" {
+		violations = append(violations, "unpinned digest")
+	}
+	if len(conf.ImageAllowedRegistries) > 0 && !slices.Contains(conf.ImageAllowedRegistries, registry) {
+		violations = append(violations, "registry not allowed")
+	}
+	return violations
+}
+
+// evaluateImages counts running pods whose containers all comply with the
+// image policies as healthy, returning the number of violating containers.
+func evaluateImages(ctx context.Context, params url.Values) (healthCount, int, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, 0, err
+	}
+	pods, err := listPods(ctx, q)
+	if err != nil {
+		return healthCount{}, 0, err
+	}
+	count := healthCount{}
+	violations := 0
+	for _, pod := range filterPods(pods, params) {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		compliant := true
+		for _, container := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+			if len(imageViolations(container.Image)) > 0 {
+				violations++
+				compliant = false
+			}
+		}
+		count.add(pod, compliant)
+	}
+	return count, violations, nil
+}
+
+func countImages(ctx context.Context, params url.Values) (healthCount, error) {
+	count, _, err := evaluateImages(ctx, params)
+	return count, err
+}
+
+func imagesBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, violations, err := evaluateImages(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	color := BADGE_COLOR_HEALTHY
+	if violations > 0 {
+		color = BADGE_COLOR_FATAL
+	}
+	return badge{
+		Label:   badgeLabel("images", params),
+		Message: fmt.Sprintf("violations: %d", violations),
+		Color:   color,
+		Count:   count,
+	}, nil
+}
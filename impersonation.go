@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// restConfigs holds the configuration behind each client, keyed by cluster
+// name with "" for the default cluster, to derive impersonating clients from.
+var restConfigs = map[string]*rest.Config{}
+
+type impersonationKey struct{}
+
+// withImpersonation makes the badge computed with ctx call the API as user.
+// It is only set from the config, so requests cannot pick an identity.
+func withImpersonation(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, impersonationKey{}, user)
+}
+
+func impersonatedUser(ctx context.Context) string {
+	user, _ := ctx.Value(impersonationKey{}).(string)
+	return user
+}
+
+type clientPair struct {
+	typed   kubernetes.Interface
+	dynamic dynamic.Interface
+}
+
+var (
+	impersonatingMu sync.Mutex
+	// impersonating caches the clients per cluster and user; they share the
+	// base configuration and differ only in the impersonation headers.
+	impersonating = map[[2]string]clientPair{}
+)
+
+func impersonatingClients(cluster, user string) (clientPair, error) {
+	impersonatingMu.Lock()
+	defer impersonatingMu.Unlock()
+	key := [2]string{cluster, user}
+	if clients, ok := impersonating[key]; ok {
+		return clients, nil
+	}
+	base, ok := restConfigs[cluster]
+	if !ok {
+		return clientPair{}, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("cannot impersonate %s: no client configuration", user))
+	}
+	config := rest.CopyConfig(base)
+	config.Impersonate = rest.ImpersonationConfig{UserName: user}
+	typed, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return clientPair{}, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return clientPair{}, err
+	}
+	clients := clientPair{typed: typed, dynamic: dynamicClient}
+	impersonating[key] = clients
+	return clients, nil
+}
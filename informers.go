@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+var informerFactory informers.SharedInformerFactory
+
+// namespaceFactories holds the per-namespace factories started for APP_NAMESPACES.
+var namespaceFactories = map[string]informers.SharedInformerFactory{}
+
+// namespaceInformers returns the factory whose informers cover namespace.
+func namespaceInformers(namespace string) informers.SharedInformerFactory {
+	if factory, ok := namespaceFactories[namespace]; ok {
+		return factory
+	}
+	return informerFactory
+}
+
+// informerKinds holds the kinds whose informers were started; other kinds keep
+// listing against the API server.
+var informerKinds = map[string]bool{}
+
+// informerStores holds the stores of the started informers by kind, one per
+// factory, for /debug/cache on the admin listener.
+var informerStores = map[string][]cache.Store{}
+
+func useInformer(kind string) bool {
+	return informerFactory != nil && informerKinds[kind]
+}
+
+// startInformers starts shared informers for the resources behind the enabled
+// badges and blocks until their caches are synced. With APP_NAMESPACES the
+// namespaced informers run per namespace so that a Role in each of them suffices.
+func startInformers(ctx context.Context, client kubernetes.Interface) error {
+	factory := informers.NewSharedInformerFactory(client, conf.ResyncPeriod)
+	factories := []informers.SharedInformerFactory{factory}
+	namespaced := map[string]informers.SharedInformerFactory{}
+	for _, namespace := range conf.Namespaces {
+		namespaced[namespace] = informers.NewSharedInformerFactoryWithOptions(client, conf.ResyncPeriod, informers.WithNamespace(namespace))
+		factories = append(factories, namespaced[namespace])
+	}
+	kinds := map[string]bool{}
+	if conf.EnableNodes || conf.EnablePods || conf.EnableCapacity || conf.EnableTopology {
+		notifyOnChange(factory.Core().V1().Nodes().Informer(), "nodes")
+		kinds["nodes"] = true
+	}
+	if len(namespaced) == 0 {
+		registerNamespacedInformers(factory, kinds)
+	}
+	for _, factory := range namespaced {
+		registerNamespacedInformers(factory, kinds)
+	}
+
+	for _, factory := range factories {
+		factory.Start(ctx.Done())
+		for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+			if !synced {
+				return fmt.Errorf("failed to sync informer for %v", informerType)
+			}
+		}
+	}
+	slog.Info("informers synced", "count", len(kinds))
+	informerFactory = factory
+	namespaceFactories = namespaced
+	informerKinds = kinds
+	return nil
+}
+
+func registerNamespacedInformers(factory informers.SharedInformerFactory, kinds map[string]bool) {
+	if conf.EnablePods || conf.EnableImagePull || conf.EnableImages || conf.EnableRestarts || conf.EnablePending || conf.EnableCapacity || conf.EnableTopology {
+		notifyOnChange(factory.Core().V1().Pods().Informer(), "pods")
+		kinds["pods"] = true
+	}
+	if conf.EnablePods || conf.EnablePVCs {
+		notifyOnChange(factory.Core().V1().PersistentVolumeClaims().Informer(), "pvcs")
+		kinds["pvcs"] = true
+	}
+	if conf.EnableQuotas {
+		notifyOnChange(factory.Core().V1().ResourceQuotas().Informer(), "resourcequotas")
+		kinds["resourcequotas"] = true
+	}
+	if conf.EnableDeployments || conf.EnableRollouts || conf.EnableTopology {
+		notifyOnChange(factory.Apps().V1().Deployments().Informer(), "deployments")
+		kinds["deployments"] = true
+	}
+	if conf.EnableRollouts {
+		notifyOnChange(factory.Apps().V1().ReplicaSets().Informer(), "replicasets")
+		kinds["replicasets"] = true
+	}
+	if conf.EnableStatefulSets {
+		notifyOnChange(factory.Apps().V1().StatefulSets().Informer(), "statefulsets")
+		kinds["statefulsets"] = true
+	}
+	if conf.EnableDaemonSets {
+		notifyOnChange(factory.Apps().V1().DaemonSets().Informer(), "daemonsets")
+		kinds["daemonsets"] = true
+	}
+	if conf.EnableJobs || conf.EnableCronJobs {
+		notifyOnChange(factory.Batch().V1().Jobs().Informer(), "jobs")
+		kinds["jobs"] = true
+	}
+	if conf.EnableCronJobs {
+		notifyOnChange(factory.Batch().V1().CronJobs().Informer(), "cronjobs")
+		kinds["cronjobs"] = true
+	}
+	if conf.EnableHPAs {
+		notifyOnChange(factory.Autoscaling().V2().HorizontalPodAutoscalers().Informer(), "hpas")
+		kinds["hpas"] = true
+	}
+	if conf.EnableIngresses {
+		notifyOnChange(factory.Networking().V1().Ingresses().Informer(), "ingresses")
+		kinds["ingresses"] = true
+	}
+	if conf.EnableServices {
+		notifyOnChange(factory.Core().V1().Services().Informer(), "services")
+		kinds["services"] = true
+		notifyOnChange(factory.Discovery().V1().EndpointSlices().Informer(), "endpointslices")
+		kinds["endpointslices"] = true
+	}
+	if conf.EnablePDB {
+		notifyOnChange(factory.Policy().V1().PodDisruptionBudgets().Informer(), "pdbs")
+		kinds["pdbs"] = true
+	}
+}
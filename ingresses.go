@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "ingresses",
+			enabled: func() bool { return conf.EnableIngresses },
+			badge:   ingressesBadge,
+		},
+		count: countIngresses,
+	})
+}
+
+var probeClient = &http.Client{
+	// Report the first response rather than following redirects to login pages and the like.
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// ingressURLs returns the URL to probe for each concrete host of the ingress,
+// using https for hosts listed under spec.tls.
+func ingressURLs(ingress *networkingv1.Ingress) []string {
+	tls := map[string]bool{}
+	for _, entry := range ingress.Spec.TLS {
+		for _, host := range entry.Hosts {
+			tls[host] = true
+		}
+	}
+	var urls []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host == "" || strings.HasPrefix(rule.Host, "*") {
+			continue
+		}
+		scheme := "http"
+		if tls[rule.Host] {
+			scheme = "https"
+		}
+		urls = append(urls, scheme+"://"+rule.Host+"/")
+	}
+	return urls
+}
+
+// probe reports whether target answers within APP_PROBE_TIMEOUT without a 5xx.
+func probe(ctx context.Context, target string) bool {
+	ctx, cancel := context.WithTimeout(ctx, conf.ProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return false
+	}
+	res, err := probeClient.Do(req)
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+	return res.StatusCode < http.StatusInternalServerError
+}
+
+// isIngressReachable probes every host of the ingress when probing is enabled;
+// otherwise, and for ingresses without concrete hosts, it checks that the
+// controller published a load balancer address.
+func isIngressReachable(ctx context.Context, ingress *networkingv1.Ingress, probing bool, limit chan struct{}) bool {
+	urls := ingressURLs(ingress)
+	if !probing || len(urls) == 0 {
+		return len(ingress.Status.LoadBalancer.Ingress) > 0
+	}
+	for _, target := range urls {
+		limit <- struct{}{}
+		ok := probe(ctx, target)
+		<-limit
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func countIngresses(ctx context.Context, params url.Values) (healthCount, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	ingresses, err := listIngresses(ctx, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	probing := conf.IngressProbe
+	if value := params.Get("probe"); value != "" {
+		probing = value == "true"
+	}
+	annotation := params.Get("annotation")
+	limit := make(chan struct{}, max(conf.ProbeConcurrency, 1))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	count := healthCount{}
+	for _, ingress := range ingresses {
+		if !matchAnnotation(ingress, annotation) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reachable := isIngressReachable(ctx, ingress, probing, limit)
+			mu.Lock()
+			count.add(ingress, reachable)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return count, nil
+}
+
+func ingressesBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countIngresses(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	return countBadge(badgeLabel("ingresses", params), count, params), nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/url"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// informerReads collects the kinds a badge computation read from informers, so
+// the badge can be invalidated when one of them changes.
+type informerReads struct {
+	mu    sync.Mutex
+	kinds map[string]bool
+}
+
+type informerReadsKey struct{}
+
+func withInformerReads(ctx context.Context) (context.Context, *informerReads) {
+	reads := &informerReads{kinds: map[string]bool{}}
+	return context.WithValue(ctx, informerReadsKey{}, reads), reads
+}
+
+func recordInformerRead(ctx context.Context, kind string) {
+	reads, _ := ctx.Value(informerReadsKey{}).(*informerReads)
+	if reads == nil {
+		return
+	}
+	reads.mu.Lock()
+	defer reads.mu.Unlock()
+	reads.kinds[kind] = true
+}
+
+func (r *informerReads) list() map[string]bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.kinds)
+}
+
+var (
+	dependenciesMu sync.Mutex
+	// cacheDependencies maps badgeCache keys to the informer kinds they read.
+	cacheDependencies = map[string]map[string]bool{}
+	// badgeDependencies maps badge names to the informer kinds they read, for
+	// recomputing their precomputed evaluations.
+	badgeDependencies = map[string]map[string]bool{}
+)
+
+func recordDependencies(key, name string, kinds map[string]bool) {
+	if len(kinds) == 0 {
+		return
+	}
+	dependenciesMu.Lock()
+	defer dependenciesMu.Unlock()
+	cacheDependencies[key] = kinds
+	badgeDependencies[name] = kinds
+}
+
+func dependsOn(kinds, changed map[string]bool) bool {
+	for kind := range kinds {
+		if changed[kind] {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	changedKindsMu sync.Mutex
+	changedKinds   = map[string]bool{}
+	// kindChanges is signalled when changedKinds gains a kind.
+	kindChanges = make(chan struct{}, 1)
+)
+
+// markChanged records an informer event for kind. Resyncs deliver updates with
+// an unchanged resource version and are ignored.
+func markChanged(kind string, old, obj any) {
+	if old != nil {
+		oldMeta, err1 := meta.Accessor(old)
+		newMeta, err2 := meta.Accessor(obj)
+		if err1 == nil && err2 == nil && oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+			return
+		}
+	}
+	changedKindsMu.Lock()
+	changedKinds[kind] = true
+	changedKindsMu.Unlock()
+	select {
+	case kindChanges <- struct{}{}:
+	default:
+	}
+}
+
+func takeChangedKinds() map[string]bool {
+	changedKindsMu.Lock()
+	defer changedKindsMu.Unlock()
+	changed := changedKinds
+	changedKinds = map[string]bool{}
+	return changed
+}
+
+// watchInvalidation drops the cached badges that read a changed kind and
+// recomputes the listed ones among them. Changes are debounced until the kinds
+// have been quiet for debounce, but never held longer than maxDelay, so a long
+// rollout still updates its badges while it progresses.
+func watchInvalidation(ctx context.Context, debounce, maxDelay time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-kindChanges:
+		}
+		deadline := time.After(maxDelay)
+		quiet := time.NewTimer(debounce)
+	debouncing:
+		for {
+			select {
+			case <-ctx.Done():
+				quiet.Stop()
+				return
+			case <-kindChanges:
+				quiet.Reset(debounce)
+			case <-quiet.C:
+				break debouncing
+			case <-deadline:
+				quiet.Stop()
+				break debouncing
+			}
+		}
+		invalidate(ctx, takeChangedKinds())
+	}
+}
+
+// invalidate recomputes the listed badges through the refresh results when
+// APP_REFRESH_INTERVAL is set, and into badgeCache otherwise.
+func invalidate(ctx context.Context, changed map[string]bool) {
+	if len(changed) == 0 {
+		return
+	}
+	dependenciesMu.Lock()
+	var keys []string
+	for key, kinds := range cacheDependencies {
+		if dependsOn(kinds, changed) {
+			keys = append(keys, key)
+			delete(cacheDependencies, key)
+		}
+	}
+	badges := map[string]bool{}
+	for name, kinds := range badgeDependencies {
+		badges[name] = dependsOn(kinds, changed)
+	}
+	dependenciesMu.Unlock()
+	for _, key := range keys {
+		badgeCache.delete(key)
+	}
+	recomputed := 0
+	for _, l := range listedBadges() {
+		if !badges[l.name] {
+			continue
+		}
+		if conf.RefreshInterval > 0 {
+			refreshBadge(ctx, l)
+		} else {
+			params := url.Values{}
+			for key, values := range l.params {
+				params[key] = values
+			}
+			params.Set("nocache", "true")
+			computeBadge(ctx, l.name, params, l.compute)
+		}
+		recomputed++
+	}
+	slog.Debug("badges invalidated", "kinds", changed, "cached", len(keys), "recomputed", recomputed)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "jobs",
+			enabled: func() bool { return conf.EnableJobs },
+			badge:   jobsBadge,
+		},
+		count: countJobs,
+	})
+}
+
+// isJobPod reports whether pod is owned by a Job, which covers CronJob runs too.
+func isJobPod(pod *corev1.Pod) bool {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "Job" {
+			return true
+		}
+	}
+	return false
+}
+
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) *batchv1.JobCondition {
+	for i := range job.Status.Conditions {
+		condition := &job.Status.Conditions[i]
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return condition
+		}
+	}
+	return nil
+}
+
+// countJobs counts jobs that finished within the window (APP_JOB_WINDOW or
+// ?window=) and treats the ones that completed as healthy.
+func countJobs(ctx context.Context, params url.Values) (healthCount, error) {
+	window := conf.JobWindow
+	if value := params.Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return healthCount{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid window: %s", value))
+		}
+		window = parsed
+	}
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	jobs, err := listJobs(ctx, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	since := time.Now().Add(-window)
+	annotation := params.Get("annotation")
+	count := healthCount{}
+	for _, job := range jobs {
+		if !matchAnnotation(job, annotation) {
+			continue
+		}
+		finished := jobCondition(job, batchv1.JobComplete)
+		if finished == nil {
+			finished = jobCondition(job, batchv1.JobFailed)
+		}
+		if finished == nil || finished.LastTransitionTime.Time.Before(since) {
+			continue
+		}
+		count.add(job, finished.Type == batchv1.JobComplete)
+	}
+	return count, nil
+}
+
+func jobsBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countJobs(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	return countBadge(badgeLabel("jobs", params), count, params), nil
+}
@@ -0,0 +1,620 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+type listQuery struct {
+	NoCache bool
+	// Cluster names an entry of APP_CLUSTERS; empty means the default client.
+	Cluster string
+	// Namespaces restricts namespaced resources; empty means all namespaces.
+	Namespaces    []string
+	LabelSelector string
+	FieldSelector string
+	// Impersonate is the user the calls are made as, set by named badges
+	// through the request context rather than a query parameter.
+	Impersonate string
+}
+
+func newListQuery(ctx context.Context, params url.Values) (listQuery, error) {
+	selector := params.Get("selector")
+	if _, err := labels.Parse(selector); err != nil {
+		return listQuery{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid selector: %s", selector))
+	}
+	fieldSelector := params.Get("fieldSelector")
+	if _, err := fields.ParseSelector(fieldSelector); err != nil {
+		return listQuery{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid fieldSelector: %s", fieldSelector))
+	}
+	cluster := params.Get("cluster")
+	if err := validateCluster(cluster); err != nil {
+		return listQuery{}, err
+	}
+	namespaces, err := scopedNamespaces(splitList(params.Get("namespace")))
+	if err != nil {
+		return listQuery{}, err
+	}
+	user := impersonatedUser(ctx)
+	if user != "" {
+		if _, err := impersonatingClients(cluster, user); err != nil {
+			return listQuery{}, err
+		}
+	}
+	return listQuery{
+		NoCache:       params.Get("nocache") == "true",
+		Cluster:       cluster,
+		Namespaces:    namespaces,
+		LabelSelector: selector,
+		FieldSelector: fieldSelector,
+		Impersonate:   user,
+	}, nil
+}
+
+// selectableFields lists the fields each kind can be selected by besides
+// metadata.name and metadata.namespace, as implemented by the API server.
+var selectableFields = map[string][]string{
+	"pods":        {"spec.nodeName", "spec.restartPolicy", "spec.schedulerName", "spec.serviceAccountName", "spec.hostNetwork", "status.phase", "status.podIP", "status.nominatedNodeName"},
+	"nodes":       {"spec.unschedulable"},
+	"events":      {"involvedObject.kind", "involvedObject.namespace", "involvedObject.name", "involvedObject.uid", "involvedObject.apiVersion", "involvedObject.resourceVersion", "involvedObject.fieldPath", "reason", "reportingComponent", "source", "type"},
+	"jobs":        {"status.successful"},
+	"replicasets": {"status.replicas"},
+	// helmreleasesecrets narrows secrets by type itself.
+	"helmreleasesecrets": {"type"},
+}
+
+// checkFieldSelector rejects field selectors the API server would refuse for
+// kind, so the badge names the offending field.
+func (q listQuery) checkFieldSelector(kind string) error {
+	selector, err := fields.ParseSelector(q.FieldSelector)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid fieldSelector: %s", q.FieldSelector))
+	}
+	for _, requirement := range selector.Requirements() {
+		field := requirement.Field
+		if field != "metadata.name" && field != "metadata.namespace" && !slices.Contains(selectableFields[kind], field) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unsupported fieldSelector for %s: %s", kind, field))
+		}
+	}
+	return nil
+}
+
+// withFieldSelector adds selector to the field selector of q.
+func (q listQuery) withFieldSelector(selector string) listQuery {
+	if q.FieldSelector != "" {
+		selector = q.FieldSelector + "," + selector
+	}
+	q.FieldSelector = selector
+	return q
+}
+
+// scopedNamespaces confines requested to APP_NAMESPACES when it is set, so that
+// every LIST is namespaced and a Role in each namespace is enough. An empty
+// request then means all configured namespaces.
+func scopedNamespaces(requested []string) ([]string, error) {
+	if len(conf.Namespaces) == 0 {
+		return requested, nil
+	}
+	if len(requested) == 0 {
+		return conf.Namespaces, nil
+	}
+	for _, namespace := range requested {
+		if !slices.Contains(conf.Namespaces, namespace) {
+			return nil, echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("namespace not allowed: %s", namespace))
+		}
+	}
+	return requested, nil
+}
+
+func (q listQuery) cacheKey(kind, namespace string) string {
+	return q.Impersonate + "@" + q.Cluster + "/" + kind + "/" + namespace + "?" + q.LabelSelector + "&" + q.FieldSelector
+}
+
+func (q listQuery) client() kubernetes.Interface {
+	if q.Impersonate != "" {
+		return q.impersonatingClients().typed
+	}
+	return clusterClient(q.Cluster)
+}
+
+func (q listQuery) dynamicClient() dynamic.Interface {
+	if q.Impersonate != "" {
+		return q.impersonatingClients().dynamic
+	}
+	return clusterDynamicClient(q.Cluster)
+}
+
+// impersonatingClients returns the clients newListQuery already built for the
+// query's identity; never falling back to the service's own clients.
+func (q listQuery) impersonatingClients() clientPair {
+	clients, err := impersonatingClients(q.Cluster, q.Impersonate)
+	if err != nil {
+		panic(err)
+	}
+	return clients
+}
+
+// useInformer reports whether kind is served from an informer; informers only
+// run against the default cluster under the service's own identity, and
+// listers cannot select by field. Informer reads are recorded on ctx so watch
+// events can invalidate the badge.
+func (q listQuery) useInformer(ctx context.Context, kind string) bool {
+	if q.Cluster != "" || q.Impersonate != "" || q.FieldSelector != "" || !useInformer(kind) {
+		return false
+	}
+	recordInformerRead(ctx, kind)
+	return true
+}
+
+func (q listQuery) selector() labels.Selector {
+	selector, err := labels.Parse(q.LabelSelector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return selector
+}
+
+// listOptions also asks the API server to give up after APP_K8S_TIMEOUT.
+func (q listQuery) listOptions() v1.ListOptions {
+	opts := v1.ListOptions{LabelSelector: q.LabelSelector, FieldSelector: q.FieldSelector}
+	if conf.K8sTimeout > 0 {
+		seconds := int64(math.Ceil(conf.K8sTimeout.Seconds()))
+		opts.TimeoutSeconds = &seconds
+	}
+	return opts
+}
+
+// withK8sTimeout bounds a single Kubernetes API call by APP_K8S_TIMEOUT.
+func withK8sTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if conf.K8sTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, conf.K8sTimeout)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// listNamespaced runs list once per requested namespace (or once across all
+// namespaces) and concatenates the results, cached unless kind is served by an informer.
+func listNamespaced[T any](ctx context.Context, kind string, q listQuery, list func(ctx context.Context, namespace string, opts v1.ListOptions) ([]T, error)) ([]T, error) {
+	if err := q.checkFieldSelector(kind); err != nil {
+		return nil, err
+	}
+	namespaces := q.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{v1.NamespaceAll}
+	}
+	var items []T
+	for _, namespace := range namespaces {
+		load := func() ([]T, error) {
+			ctx, cancel := withK8sTimeout(ctx)
+			defer cancel()
+			return list(ctx, namespace, q.listOptions())
+		}
+		var namespaceItems []T
+		var err error
+		if q.useInformer(ctx, kind) {
+			namespaceItems, err = load()
+		} else {
+			namespaceItems, err = cached(ctx, listCache, q.cacheKey(kind, namespace), q.NoCache, load)
+			recordClusterCall(q.Cluster, err)
+		}
+		if err != nil {
+			kubernetesErrorsTotal.WithLabelValues(kind, q.Cluster).Inc()
+			return nil, err
+		}
+		if len(namespaces) == 1 {
+			return namespaceItems, nil
+		}
+		items = append(items, namespaceItems...)
+	}
+	return items, nil
+}
+
+// listPages pages through a LIST conf.ListPageSize objects at a time so that no
+// single response from the API server grows with the size of the cluster.
+func listPages[T any](opts v1.ListOptions, list func(opts v1.ListOptions) ([]T, string, error)) ([]T, error) {
+	opts.Limit = int64(conf.ListPageSize)
+	var items []T
+	for {
+		page, next, err := list(opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		if next == "" {
+			return items, nil
+		}
+		opts.Continue = next
+	}
+}
+
+func pointers[T any](items []T) []*T {
+	result := make([]*T, len(items))
+	for i := range items {
+		result[i] = &items[i]
+	}
+	return result
+}
+
+func listPods(ctx context.Context, q listQuery) ([]*corev1.Pod, error) {
+	return listNamespaced(ctx, "pods", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*corev1.Pod, error) {
+		if q.useInformer(ctx, "pods") {
+			return namespaceInformers(namespace).Core().V1().Pods().Lister().Pods(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*corev1.Pod, string, error) {
+			pods, err := q.client().CoreV1().Pods(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(pods.Items), pods.Continue, nil
+		})
+	})
+}
+
+func listNodes(ctx context.Context, q listQuery) ([]*corev1.Node, error) {
+	if err := q.checkFieldSelector("nodes"); err != nil {
+		return nil, err
+	}
+	if q.useInformer(ctx, "nodes") {
+		return informerFactory.Core().V1().Nodes().Lister().List(q.selector())
+	}
+	nodes, err := cached(ctx, listCache, q.cacheKey("nodes", ""), q.NoCache, func() ([]*corev1.Node, error) {
+		ctx, cancel := withK8sTimeout(ctx)
+		defer cancel()
+		return listPages(q.listOptions(), func(opts v1.ListOptions) ([]*corev1.Node, string, error) {
+			nodes, err := q.client().CoreV1().Nodes().List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(nodes.Items), nodes.Continue, nil
+		})
+	})
+	recordClusterCall(q.Cluster, err)
+	if err != nil {
+		kubernetesErrorsTotal.WithLabelValues("nodes", q.Cluster).Inc()
+	}
+	return nodes, err
+}
+
+func listDeployments(ctx context.Context, q listQuery) ([]*appsv1.Deployment, error) {
+	return listNamespaced(ctx, "deployments", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*appsv1.Deployment, error) {
+		if q.useInformer(ctx, "deployments") {
+			return namespaceInformers(namespace).Apps().V1().Deployments().Lister().Deployments(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*appsv1.Deployment, string, error) {
+			deployments, err := q.client().AppsV1().Deployments(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(deployments.Items), deployments.Continue, nil
+		})
+	})
+}
+
+func getPod(ctx context.Context, q listQuery, namespace, name string) (*corev1.Pod, error) {
+	if _, err := scopedNamespaces([]string{namespace}); err != nil {
+		return nil, err
+	}
+	if q.useInformer(ctx, "pods") {
+		return namespaceInformers(namespace).Core().V1().Pods().Lister().Pods(namespace).Get(name)
+	}
+	return cached(ctx, listCache, q.cacheKey("pod", namespace+"/"+name), q.NoCache, func() (*corev1.Pod, error) {
+		ctx, cancel := withK8sTimeout(ctx)
+		defer cancel()
+		return q.client().CoreV1().Pods(namespace).Get(ctx, name, v1.GetOptions{})
+	})
+}
+
+func getNode(ctx context.Context, q listQuery, name string) (*corev1.Node, error) {
+	if q.useInformer(ctx, "nodes") {
+		return informerFactory.Core().V1().Nodes().Lister().Get(name)
+	}
+	return cached(ctx, listCache, q.cacheKey("node", name), q.NoCache, func() (*corev1.Node, error) {
+		ctx, cancel := withK8sTimeout(ctx)
+		defer cancel()
+		return q.client().CoreV1().Nodes().Get(ctx, name, v1.GetOptions{})
+	})
+}
+
+func getDeployment(ctx context.Context, q listQuery, namespace, name string) (*appsv1.Deployment, error) {
+	if _, err := scopedNamespaces([]string{namespace}); err != nil {
+		return nil, err
+	}
+	if q.useInformer(ctx, "deployments") {
+		return namespaceInformers(namespace).Apps().V1().Deployments().Lister().Deployments(namespace).Get(name)
+	}
+	return cached(ctx, listCache, q.cacheKey("deployment", namespace+"/"+name), q.NoCache, func() (*appsv1.Deployment, error) {
+		ctx, cancel := withK8sTimeout(ctx)
+		defer cancel()
+		return q.client().AppsV1().Deployments(namespace).Get(ctx, name, v1.GetOptions{})
+	})
+}
+
+func listStatefulSets(ctx context.Context, q listQuery) ([]*appsv1.StatefulSet, error) {
+	return listNamespaced(ctx, "statefulsets", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*appsv1.StatefulSet, error) {
+		if q.useInformer(ctx, "statefulsets") {
+			return namespaceInformers(namespace).Apps().V1().StatefulSets().Lister().StatefulSets(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*appsv1.StatefulSet, string, error) {
+			statefulSets, err := q.client().AppsV1().StatefulSets(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(statefulSets.Items), statefulSets.Continue, nil
+		})
+	})
+}
+
+func listDaemonSets(ctx context.Context, q listQuery) ([]*appsv1.DaemonSet, error) {
+	return listNamespaced(ctx, "daemonsets", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*appsv1.DaemonSet, error) {
+		if q.useInformer(ctx, "daemonsets") {
+			return namespaceInformers(namespace).Apps().V1().DaemonSets().Lister().DaemonSets(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*appsv1.DaemonSet, string, error) {
+			daemonSets, err := q.client().AppsV1().DaemonSets(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(daemonSets.Items), daemonSets.Continue, nil
+		})
+	})
+}
+
+func listPDBs(ctx context.Context, q listQuery) ([]*policyv1.PodDisruptionBudget, error) {
+	return listNamespaced(ctx, "pdbs", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*policyv1.PodDisruptionBudget, error) {
+		if q.useInformer(ctx, "pdbs") {
+			return namespaceInformers(namespace).Policy().V1().PodDisruptionBudgets().Lister().PodDisruptionBudgets(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*policyv1.PodDisruptionBudget, string, error) {
+			pdbs, err := q.client().PolicyV1().PodDisruptionBudgets(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(pdbs.Items), pdbs.Continue, nil
+		})
+	})
+}
+
+func listJobs(ctx context.Context, q listQuery) ([]*batchv1.Job, error) {
+	return listNamespaced(ctx, "jobs", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*batchv1.Job, error) {
+		if q.useInformer(ctx, "jobs") {
+			return namespaceInformers(namespace).Batch().V1().Jobs().Lister().Jobs(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*batchv1.Job, string, error) {
+			jobs, err := q.client().BatchV1().Jobs(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(jobs.Items), jobs.Continue, nil
+		})
+	})
+}
+
+// listCustom lists a custom resource through the dynamic client. A missing
+// resource type surfaces as a 404 so badges for uninstalled CRDs stay readable.
+func listCustom(ctx context.Context, gvr schema.GroupVersionResource, q listQuery) ([]*unstructured.Unstructured, error) {
+	return listNamespaced(ctx, gvr.String(), q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*unstructured.Unstructured, error) {
+		items, err := listPages(opts, func(opts v1.ListOptions) ([]*unstructured.Unstructured, string, error) {
+			list, err := q.dynamicClient().Resource(gvr).Namespace(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(list.Items), list.GetContinue(), nil
+		})
+		if apierrors.IsNotFound(err) {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("%s not available", gvr.GroupResource()))
+		}
+		return items, err
+	})
+}
+
+func listHPAs(ctx context.Context, q listQuery) ([]*autoscalingv2.HorizontalPodAutoscaler, error) {
+	return listNamespaced(ctx, "hpas", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*autoscalingv2.HorizontalPodAutoscaler, error) {
+		if q.useInformer(ctx, "hpas") {
+			return namespaceInformers(namespace).Autoscaling().V2().HorizontalPodAutoscalers().Lister().HorizontalPodAutoscalers(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*autoscalingv2.HorizontalPodAutoscaler, string, error) {
+			hpas, err := q.client().AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(hpas.Items), hpas.Continue, nil
+		})
+	})
+}
+
+func listIngresses(ctx context.Context, q listQuery) ([]*networkingv1.Ingress, error) {
+	return listNamespaced(ctx, "ingresses", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*networkingv1.Ingress, error) {
+		if q.useInformer(ctx, "ingresses") {
+			return namespaceInformers(namespace).Networking().V1().Ingresses().Lister().Ingresses(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*networkingv1.Ingress, string, error) {
+			ingresses, err := q.client().NetworkingV1().Ingresses(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(ingresses.Items), ingresses.Continue, nil
+		})
+	})
+}
+
+func listEvents(ctx context.Context, q listQuery) ([]*corev1.Event, error) {
+	return listNamespaced(ctx, "events", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*corev1.Event, error) {
+		return listPages(opts, func(opts v1.ListOptions) ([]*corev1.Event, string, error) {
+			events, err := q.client().CoreV1().Events(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(events.Items), events.Continue, nil
+		})
+	})
+}
+
+func listCronJobs(ctx context.Context, q listQuery) ([]*batchv1.CronJob, error) {
+	return listNamespaced(ctx, "cronjobs", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*batchv1.CronJob, error) {
+		if q.useInformer(ctx, "cronjobs") {
+			return namespaceInformers(namespace).Batch().V1().CronJobs().Lister().CronJobs(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*batchv1.CronJob, string, error) {
+			cronJobs, err := q.client().BatchV1().CronJobs(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(cronJobs.Items), cronJobs.Continue, nil
+		})
+	})
+}
+
+func listPVCs(ctx context.Context, q listQuery) ([]*corev1.PersistentVolumeClaim, error) {
+	return listNamespaced(ctx, "pvcs", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*corev1.PersistentVolumeClaim, error) {
+		if q.useInformer(ctx, "pvcs") {
+			return namespaceInformers(namespace).Core().V1().PersistentVolumeClaims().Lister().PersistentVolumeClaims(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*corev1.PersistentVolumeClaim, string, error) {
+			pvcs, err := q.client().CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(pvcs.Items), pvcs.Continue, nil
+		})
+	})
+}
+
+func listReplicaSets(ctx context.Context, q listQuery) ([]*appsv1.ReplicaSet, error) {
+	return listNamespaced(ctx, "replicasets", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*appsv1.ReplicaSet, error) {
+		if q.useInformer(ctx, "replicasets") {
+			return namespaceInformers(namespace).Apps().V1().ReplicaSets().Lister().ReplicaSets(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*appsv1.ReplicaSet, string, error) {
+			replicaSets, err := q.client().AppsV1().ReplicaSets(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(replicaSets.Items), replicaSets.Continue, nil
+		})
+	})
+}
+
+func listResourceQuotas(ctx context.Context, q listQuery) ([]*corev1.ResourceQuota, error) {
+	return listNamespaced(ctx, "resourcequotas", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*corev1.ResourceQuota, error) {
+		if q.useInformer(ctx, "resourcequotas") {
+			return namespaceInformers(namespace).Core().V1().ResourceQuotas().Lister().ResourceQuotas(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*corev1.ResourceQuota, string, error) {
+			quotas, err := q.client().CoreV1().ResourceQuotas(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(quotas.Items), quotas.Continue, nil
+		})
+	})
+}
+
+func listServices(ctx context.Context, q listQuery) ([]*corev1.Service, error) {
+	return listNamespaced(ctx, "services", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*corev1.Service, error) {
+		if q.useInformer(ctx, "services") {
+			return namespaceInformers(namespace).Core().V1().Services().Lister().Services(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*corev1.Service, string, error) {
+			services, err := q.client().CoreV1().Services(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(services.Items), services.Continue, nil
+		})
+	})
+}
+
+func getService(ctx context.Context, q listQuery, namespace, name string) (*corev1.Service, error) {
+	if _, err := scopedNamespaces([]string{namespace}); err != nil {
+		return nil, err
+	}
+	if q.useInformer(ctx, "services") {
+		return namespaceInformers(namespace).Core().V1().Services().Lister().Services(namespace).Get(name)
+	}
+	return cached(ctx, listCache, q.cacheKey("service", namespace+"/"+name), q.NoCache, func() (*corev1.Service, error) {
+		ctx, cancel := withK8sTimeout(ctx)
+		defer cancel()
+		return q.client().CoreV1().Services(namespace).Get(ctx, name, v1.GetOptions{})
+	})
+}
+
+func listEndpointSlices(ctx context.Context, q listQuery) ([]*discoveryv1.EndpointSlice, error) {
+	return listNamespaced(ctx, "endpointslices", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]*discoveryv1.EndpointSlice, error) {
+		if q.useInformer(ctx, "endpointslices") {
+			return namespaceInformers(namespace).Discovery().V1().EndpointSlices().Lister().EndpointSlices(namespace).List(q.selector())
+		}
+		return listPages(opts, func(opts v1.ListOptions) ([]*discoveryv1.EndpointSlice, string, error) {
+			slices, err := q.client().DiscoveryV1().EndpointSlices(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(slices.Items), slices.Continue, nil
+		})
+	})
+}
+
+// helmReleaseSecret is a release Secret reduced to its metadata and decoded
+// release.
+type helmReleaseSecret struct {
+	meta    v1.ObjectMeta
+	release helmRelease
+}
+
+// listHelmReleases lists the Secrets in which Helm v3 stores releases and
+// decodes them. Secrets are never watched through informers, and only the
+// decoded releases are cached, to keep their charts and values out of memory
+// between requests. Secrets that fail to decode are skipped.
+func listHelmReleases(ctx context.Context, q listQuery) ([]helmReleaseSecret, error) {
+	q = q.withFieldSelector("type=" + HELM_RELEASE_SECRET_TYPE)
+	return listNamespaced(ctx, "helmreleasesecrets", q, func(ctx context.Context, namespace string, opts v1.ListOptions) ([]helmReleaseSecret, error) {
+		return listPages(opts, func(opts v1.ListOptions) ([]helmReleaseSecret, string, error) {
+			secrets, err := q.client().CoreV1().Secrets(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			releases := make([]helmReleaseSecret, 0, len(secrets.Items))
+			for i := range secrets.Items {
+				secret := &secrets.Items[i]
+				release, err := decodeHelmRelease(secret)
+				if err != nil {
+					continue
+				}
+				meta := v1.ObjectMeta{Name: secret.Name, Namespace: secret.Namespace, Labels: secret.Labels, Annotations: secret.Annotations}
+				releases = append(releases, helmReleaseSecret{meta: meta, release: release})
+			}
+			return releases, secrets.Continue, nil
+		})
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const SERVICE_ACCOUNT_NAMESPACE_FILE = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// leading reports whether this replica sends notifications, writes the
+// BadgeStatus objects and records the shared history. Without leader
+// election every replica leads.
+var leading atomic.Bool
+
+func init() {
+	leading.Store(true)
+}
+
+func leaderElectionNamespace() string {
+	if conf.LeaderElectionNamespace != "" {
+		return conf.LeaderElectionNamespace
+	}
+	if data, err := os.ReadFile(SERVICE_ACCOUNT_NAMESPACE_FILE); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return v1.NamespaceDefault
+}
+
+// runLeaderElection competes for the APP_LEADER_ELECTION_ID Lease until ctx is
+// done, so that only one replica sends webhook notifications. Every replica
+// keeps serving badges from its own informers and caches. The caller clears
+// leading before starting it.
+func runLeaderElection(ctx context.Context, client kubernetes.Interface) {
+	identity, err := os.Hostname()
+	if err != nil {
+		panic(err)
+	}
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  v1.ObjectMeta{Name: conf.LeaderElectionID, Namespace: leaderElectionNamespace()},
+		Client:     client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+	for ctx.Err() == nil {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			ReleaseOnCancel: true,
+			LeaseDuration:   15 * time.Second,
+			RenewDeadline:   10 * time.Second,
+			RetryPeriod:     2 * time.Second,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(context.Context) {
+					slog.Info("started leading", "identity", identity)
+					leading.Store(true)
+				},
+				OnStoppedLeading: func() {
+					slog.Info("stopped leading", "identity", identity)
+					leading.Store(false)
+				},
+				OnNewLeader: func(leader string) {
+					slog.Debug("leader elected", "leader", leader)
+				},
+			},
+		})
+	}
+}
@@ -8,33 +8,167 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
 )
 
 type Config struct {
-	Debug bool   `default:"false"`
-	Port  string `default:"8080"`
-	Env   string `envconfig:"ENV"`
+	Debug                   bool          `default:"false"`
+	Kubeconfig              string        `envconfig:"KUBECONFIG"`
+	KubeContext             string        `envconfig:"KUBE_CONTEXT"`
+	OTLPEndpoint            string        `envconfig:"OTLP_ENDPOINT"`
+	Port                    string        `default:"8080"`
+	GRPCAddr                string        `envconfig:"GRPC_ADDR"`
+	AdminAddr               string        `envconfig:"ADMIN_ADDR"`
+	TLSCert                 string        `envconfig:"TLS_CERT"`
+	TLSKey                  string        `envconfig:"TLS_KEY"`
+	TLSClientCA             string        `envconfig:"TLS_CLIENT_CA"`
+	TLSReloadInterval       time.Duration `envconfig:"TLS_RELOAD_INTERVAL" default:"30s"`
+	Env                     string        `envconfig:"ENV"`
+	SelfTest                bool          `envconfig:"SELF_TEST" default:"false"`
+	NodeWeightResource      string        `envconfig:"NODE_WEIGHT_RESOURCE" default:"cpu"`
+	NodeFlapGrace           time.Duration `envconfig:"NODE_FLAP_GRACE" default:"0s"`
+	CordonedUnhealthy       bool          `envconfig:"CORDONED_UNHEALTHY" default:"false"`
+	MaxKubeletSkew          int           `envconfig:"MAX_KUBELET_SKEW" default:"3"`
+	NodeVersionMaxDeviation time.Duration `envconfig:"NODE_VERSION_MAX_DEVIATION" default:"24h"`
+	ImagePullThreshold      int           `envconfig:"IMAGE_PULL_THRESHOLD" default:"0"`
+	ImageDisallowLatest     bool          `envconfig:"IMAGE_DISALLOW_LATEST" default:"true"`
+	ImageRequireDigest      bool          `envconfig:"IMAGE_REQUIRE_DIGEST" default:"false"`
+	ImageAllowedRegistries  []string      `envconfig:"IMAGE_ALLOWED_REGISTRIES"`
+	WarnThreshold           float64       `envconfig:"WARN_THRESHOLD" default:"0.8"`
+	FatalThreshold          float64       `envconfig:"FATAL_THRESHOLD" default:"0.5"`
+	EmptyState              string        `envconfig:"EMPTY_STATE" default:"unknown"`
+	EmptyMessage            string        `envconfig:"EMPTY_MESSAGE"`
+	UsageWarnThreshold      float64       `envconfig:"USAGE_WARN_THRESHOLD" default:"0.8"`
+	UsageFatalThreshold     float64       `envconfig:"USAGE_FATAL_THRESHOLD" default:"0.9"`
+	CacheTTL                time.Duration `envconfig:"CACHE_TTL" default:"0s"`
+	RenderCacheSize         int           `envconfig:"RENDER_CACHE_SIZE" default:"1000"`
+	Gzip                    bool          `envconfig:"GZIP" default:"true"`
+	GzipMinLength           int           `envconfig:"GZIP_MIN_LENGTH" default:"1024"`
+	StaleLimit              time.Duration `envconfig:"STALE_LIMIT" default:"10m"`
+	StaleCacheSize          int           `envconfig:"STALE_CACHE_SIZE" default:"1000"`
+	ListPageSize            int           `envconfig:"LIST_PAGE_SIZE" default:"500"`
+	BadgeTimeout            time.Duration `envconfig:"BADGE_TIMEOUT" default:"30s"`
+	K8sTimeout              time.Duration `envconfig:"K8S_TIMEOUT" default:"10s"`
+	ShutdownTimeout         time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"`
+	ShutdownDelay           time.Duration `envconfig:"SHUTDOWN_DELAY" default:"0s"`
+	UseInformers            bool          `envconfig:"USE_INFORMERS" default:"false"`
+	ResyncPeriod            time.Duration `envconfig:"RESYNC_PERIOD" default:"10m"`
+	WatchInvalidation       bool          `envconfig:"WATCH_INVALIDATION" default:"true"`
+	WatchDebounce           time.Duration `envconfig:"WATCH_DEBOUNCE" default:"2s"`
+	WatchMaxDelay           time.Duration `envconfig:"WATCH_MAX_DELAY" default:"15s"`
+	LeaderElection          bool          `envconfig:"LEADER_ELECTION" default:"false"`
+	LeaderElectionID        string        `envconfig:"LEADER_ELECTION_ID" default:"k8s-status-badge"`
+	LeaderElectionNamespace string        `envconfig:"LEADER_ELECTION_NAMESPACE"`
+	EnablePods              bool          `envconfig:"ENABLE_PODS" default:"true"`
+	EnableNodes             bool          `envconfig:"ENABLE_NODES" default:"true"`
+	EnableDeployments       bool          `envconfig:"ENABLE_DEPLOYMENTS" default:"true"`
+	EnableRollouts          bool          `envconfig:"ENABLE_ROLLOUTS" default:"true"`
+	EnableStatefulSets      bool          `envconfig:"ENABLE_STATEFULSETS" default:"true"`
+	EnableDaemonSets        bool          `envconfig:"ENABLE_DAEMONSETS" default:"true"`
+	EnableImagePull         bool          `envconfig:"ENABLE_IMAGE_PULL" default:"true"`
+	EnableImages            bool          `envconfig:"ENABLE_IMAGES" default:"true"`
+	EnableRestarts          bool          `envconfig:"ENABLE_RESTARTS" default:"true"`
+	EnablePending           bool          `envconfig:"ENABLE_PENDING" default:"true"`
+	EnableTopology          bool          `envconfig:"ENABLE_TOPOLOGY" default:"true"`
+	EnablePDB               bool          `envconfig:"ENABLE_PDB" default:"true"`
+	EnableServices          bool          `envconfig:"ENABLE_SERVICES" default:"true"`
+	EnablePVCs              bool          `envconfig:"ENABLE_PVCS" default:"true"`
+	EnableQuotas            bool          `envconfig:"ENABLE_QUOTAS" default:"true"`
+	EnableHPAs              bool          `envconfig:"ENABLE_HPAS" default:"true"`
+	EnableIngresses         bool          `envconfig:"ENABLE_INGRESSES" default:"true"`
+	EnableWarningEvents     bool          `envconfig:"ENABLE_WARNING_EVENTS" default:"true"`
+	EnableVersion           bool          `envconfig:"ENABLE_VERSION" default:"true"`
+	EnableHistory           bool          `envconfig:"ENABLE_HISTORY" default:"true"`
+	EnableJobs              bool          `envconfig:"ENABLE_JOBS" default:"true"`
+	EnableCronJobs          bool          `envconfig:"ENABLE_CRONJOBS" default:"true"`
+	EnableCertificates      bool          `envconfig:"ENABLE_CERTIFICATES" default:"false"`
+	EnableCustom            bool          `envconfig:"ENABLE_CUSTOM" default:"false"`
+	EnableArgoCD            bool          `envconfig:"ENABLE_ARGOCD" default:"false"`
+	EnableFlux              bool          `envconfig:"ENABLE_FLUX" default:"false"`
+	EnableHelm              bool          `envconfig:"ENABLE_HELM" default:"false"`
+	EnableUsage             bool          `envconfig:"ENABLE_USAGE" default:"false"`
+	EnableCapacity          bool          `envconfig:"ENABLE_CAPACITY" default:"true"`
+	EnableCluster           bool          `envconfig:"ENABLE_CLUSTER" default:"true"`
+	EnableProbes            bool          `envconfig:"ENABLE_PROBES" default:"true"`
+	EnableStats             bool          `envconfig:"ENABLE_STATS" default:"true"`
+	EnableMetrics           bool          `envconfig:"ENABLE_METRICS" default:"true"`
+	EnableEvents            bool          `envconfig:"ENABLE_EVENTS" default:"true"`
+	BadgeLabelField         string        `envconfig:"BADGE_LABEL_FIELD" default:"label"`
+	BadgeMessageField       string        `envconfig:"BADGE_MESSAGE_FIELD" default:"message"`
+	BadgeColorField         string        `envconfig:"BADGE_COLOR_FIELD" default:"color"`
+	BadgeLinkURL            string        `envconfig:"BADGE_LINK_URL"`
+	BadgeLabelColor         string        `envconfig:"BADGE_LABEL_COLOR"`
+	BadgeLogoSVG            string        `envconfig:"BADGE_LOGO_SVG"`
+	BadgeCacheSeconds       int           `envconfig:"BADGE_CACHE_SECONDS" default:"0"`
+	ReadyRequireResources   bool          `envconfig:"READY_REQUIRE_RESOURCES" default:"false"`
+	ReadyTimeout            time.Duration `envconfig:"READY_TIMEOUT" default:"3s"`
+	WarmupTimeout           time.Duration `envconfig:"WARMUP_TIMEOUT" default:"1m"`
+	AuthTokens              []string      `envconfig:"AUTH_TOKENS"`
+	CORSOrigins             []string      `envconfig:"CORS_ORIGINS"`
+	SigningSecret           string        `envconfig:"SIGNING_SECRET"`
+	RateLimit               float64       `envconfig:"RATE_LIMIT" default:"0"`
+	RateLimitBurst          int           `envconfig:"RATE_LIMIT_BURST" default:"20"`
+	Clusters                []string      `envconfig:"CLUSTERS"`
+	ConfigFile              string        `envconfig:"CONFIG"`
+	ConfigMaps              bool          `envconfig:"CONFIG_MAPS" default:"false"`
+	ConfigSecrets           bool          `envconfig:"CONFIG_SECRETS" default:"false"`
+	ConfigSourceSelector    string        `envconfig:"CONFIG_SOURCE_SELECTOR" default:"badge.piny940.dev/config=true"`
+	ConfigReloadInterval    time.Duration `envconfig:"CONFIG_RELOAD_INTERVAL" default:"30s"`
+	ExcludeNamespaces       []string      `envconfig:"EXCLUDE_NAMESPACES"`
+	Namespaces              []string      `envconfig:"NAMESPACES"`
+	IgnoreLabel             string        `envconfig:"IGNORE_LABEL" default:"badge.piny940.dev/ignore=true"`
+	IncludeCompleted        bool          `envconfig:"INCLUDE_COMPLETED" default:"true"`
+	JobWindow               time.Duration `envconfig:"JOB_WINDOW" default:"24h"`
+	CronJobGrace            time.Duration `envconfig:"CRONJOB_GRACE" default:"1h"`
+	CertExpiryWarning       time.Duration `envconfig:"CERT_EXPIRY_WARNING" default:"336h"`
+	ArgoCDNamespace         string        `envconfig:"ARGOCD_NAMESPACE" default:"argocd"`
+	StatusRefresh           time.Duration `envconfig:"STATUS_REFRESH" default:"0s"`
+	EventsInterval          time.Duration `envconfig:"EVENTS_INTERVAL" default:"30s"`
+	WebhookURLs             []string      `envconfig:"WEBHOOK_URLS"`
+	SlackWebhookURLs        []string      `envconfig:"SLACK_WEBHOOK_URLS"`
+	DiscordWebhookURLs      []string      `envconfig:"DISCORD_WEBHOOK_URLS"`
+	WebhookDebounce         time.Duration `envconfig:"WEBHOOK_DEBOUNCE" default:"1m"`
+	WebhookCooldown         time.Duration `envconfig:"WEBHOOK_COOLDOWN" default:"5m"`
+	StatusNamespace         string        `envconfig:"STATUS_NAMESPACE"`
+	StatusEvents            bool          `envconfig:"STATUS_EVENTS" default:"true"`
+	HistoryInterval         time.Duration `envconfig:"HISTORY_INTERVAL" default:"1m"`
+	HistorySize             int           `envconfig:"HISTORY_SIZE" default:"1440"`
+	HistoryFile             string        `envconfig:"HISTORY_FILE"`
+	HistoryDatabase         string        `envconfig:"HISTORY_DATABASE"`
+	HistoryRetention        time.Duration `envconfig:"HISTORY_RETENTION" default:"2160h"`
+	RefreshInterval         time.Duration `envconfig:"REFRESH_INTERVAL" default:"0"`
+	RefreshWorkers          int           `envconfig:"REFRESH_WORKERS" default:"4"`
+	BatchConcurrency        int           `envconfig:"BATCH_CONCURRENCY" default:"8"`
+	IngressProbe            bool          `envconfig:"INGRESS_PROBE" default:"false"`
+	ProbeTimeout            time.Duration `envconfig:"PROBE_TIMEOUT" default:"5s"`
+	ProbeConcurrency        int           `envconfig:"PROBE_CONCURRENCY" default:"5"`
+	WarningEventsWindow     time.Duration `envconfig:"WARNING_EVENTS_WINDOW" default:"15m"`
+	WarningEventsWarn       int           `envconfig:"WARNING_EVENTS_WARN" default:"1"`
+	WarningEventsFatal      int           `envconfig:"WARNING_EVENTS_FATAL" default:"10"`
+	RestartsWindow          time.Duration `envconfig:"RESTARTS_WINDOW" default:"1h"`
+	RestartsWarn            int           `envconfig:"RESTARTS_WARN" default:"1"`
+	RestartsFatal           int           `envconfig:"RESTARTS_FATAL" default:"5"`
+	PendingThreshold        time.Duration `envconfig:"PENDING_THRESHOLD" default:"10m"`
+	ProbeInterval           time.Duration `envconfig:"PROBE_INTERVAL" default:"30s"`
 }
 
 var k8sClient kubernetes.Interface
+var dynamicClient dynamic.Interface
 var conf = &Config{}
-
-const (
-	BADGE_COLOR_FATAL   = "red"
-	BADGE_COLOR_WARN    = "yellow"
-	BADGE_COLOR_HEALTHY = "blue"
-)
+var resourcesFound atomic.Bool
 
 func main() {
 	godotenv.Load()
@@ -49,109 +183,370 @@ func main() {
 		Level: logLevel,
 	})))
 	slog.Debug(fmt.Sprintf("conf: %+v", conf))
+	if _, err := parseEmptyState(nil); err != nil {
+		panic(err)
+	}
+	if conf.BadgeLogoSVG != "" {
+		if err := loadBadgeLogo(conf.BadgeLogoSVG); err != nil {
+			panic(err)
+		}
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "sign" {
+		if err := runSign(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if conf.OTLPEndpoint != "" {
+		shutdown, err := setupTracing(ctx, conf.OTLPEndpoint)
+		if err != nil {
+			panic(err)
+		}
+		defer shutdown(context.Background())
+	}
 
 	var err error
-	k8sClient, err = newClient(conf)
+	k8sClient, dynamicClient, err = newClient(conf)
+	if err != nil {
+		panic(err)
+	}
+	clusters, dynamicClusters, err = newClusters(conf.Clusters)
 	if err != nil {
 		panic(err)
 	}
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		if err := runCheck(ctx, os.Stdout, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if conf.ConfigMaps || conf.ConfigSecrets {
+		if err := startConfigSources(ctx, k8sClient); err != nil {
+			panic(err)
+		}
+	}
+	if conf.ConfigFile != "" || configSourcesStarted() {
+		if err := reloadBadgeDefs(conf.ConfigFile); err != nil {
+			panic(err)
+		}
+	}
+	if conf.ConfigFile != "" && conf.ConfigReloadInterval > 0 {
+		go watchBadgeDefs(ctx, conf.ConfigFile, conf.ConfigReloadInterval)
+	}
+	if configSourcesStarted() {
+		go watchConfigSources(ctx, conf.ConfigFile)
+	}
+	if conf.LeaderElection {
+		// Follow until elected, before anything leader-only can start.
+		leading.Store(false)
+		go runLeaderElection(ctx, k8sClient)
+	}
+	if conf.UseInformers {
+		if err := startInformers(ctx, k8sClient); err != nil {
+			panic(err)
+		}
+		if conf.WatchInvalidation {
+			go watchInvalidation(ctx, conf.WatchDebounce, conf.WatchMaxDelay)
+		}
+	}
+	if conf.SelfTest {
+		runSelfTest(ctx)
+	}
+	if conf.EnableProbes {
+		go watchProbes(ctx)
+	}
+	if conf.WarmupTimeout > 0 {
+		warmingUp.Store(true)
+		go warmUp(ctx, conf.WarmupTimeout)
+	}
+	if conf.EnableHistory {
+		store, err := openHistoryStore(ctx, conf.HistoryDatabase, conf.HistoryFile)
+		if err != nil {
+			panic(err)
+		}
+		defer store.close()
+		historyBackend = store
+		go watchHistory(ctx, conf.HistoryInterval, conf.HistoryRetention)
+	}
+	if conf.EnableEvents || len(configuredWebhooks()) > 0 || conf.StatusNamespace != "" {
+		go watchBadgeChanges(ctx)
+	}
+	if conf.RefreshInterval > 0 {
+		go watchRefresh(ctx, conf.RefreshWorkers)
+	}
+	if conf.CacheTTL > 0 {
+		go sweepCaches(ctx, max(conf.CacheTTL, time.Minute))
+	}
 
-	e := echo.New()
-	e.GET("/healthz", healthz)
-	e.HEAD("/healthz", healthz)
-	e.GET("/pods", handlePods)
-	e.GET("/nodes", handleNodes)
+	if conf.GRPCAddr != "" {
+		go func() {
+			if err := serveGRPC(ctx, conf.GRPCAddr); err != nil {
+				slog.Error("grpc server failed", "error", err.Error())
+			}
+		}()
+	}
 
-	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
+	if conf.AdminAddr != "" {
+		go func() {
+			if err := serveAdmin(ctx, conf.AdminAddr); err != nil {
+				slog.Error("admin server failed", "error", err.Error())
+			}
+		}()
+	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer stop()
+	e := newServer(conf)
 
-	go func() {
-		if err := e.Start(":" + conf.Port); err != nil && err != http.ErrServerClosed {
-			e.Logger.Fatal("shutting down the server")
+	if conf.TLSCert != "" {
+		reloader, err := newTLSReloader(conf.TLSCert, conf.TLSKey, conf.TLSClientCA)
+		if err != nil {
+			panic(err)
 		}
-	}()
+		go reloader.watch(ctx, conf.TLSReloadInterval)
+		e.TLSServer.Addr = ":" + conf.Port
+		e.TLSServer.TLSConfig = reloader.serverConfig()
+		go func() {
+			if err := e.StartServer(e.TLSServer); err != nil && err != http.ErrServerClosed {
+				e.Logger.Fatal("shutting down the server")
+			}
+		}()
+	} else {
+		go func() {
+			if err := e.Start(":" + conf.Port); err != nil && err != http.ErrServerClosed {
+				e.Logger.Fatal("shutting down the server")
+			}
+		}()
+	}
 
 	<-ctx.Done()
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if err := e.Shutdown(ctx); err != nil {
-		e.Logger.Fatal(err)
+	shutdown(e)
+}
+
+var badgeMethods = []string{http.MethodGet, http.MethodHead}
+
+func newServer(conf *Config) *echo.Echo {
+	e := echo.New()
+	e.GET("/livez", livez)
+	e.HEAD("/livez", livez)
+	e.GET("/healthz", livez)
+	e.HEAD("/healthz", livez)
+	e.GET("/readyz", readyz)
+	e.HEAD("/readyz", readyz)
+	e.GET("/favicon.ico", handleFavicon)
+	badgePaths := map[string]bool{}
+	badgeMiddleware := []echo.MiddlewareFunc{embeddable}
+	if conf.EnableStats {
+		badgeMiddleware = append(badgeMiddleware, statsMiddleware)
+	}
+	if conf.SigningSecret != "" {
+		badgeMiddleware = append(badgeMiddleware, signatureMiddleware(conf.SigningSecret))
+	}
+	if conf.BadgeTimeout > 0 {
+		// Cancels the request context so a hung API server ends in a stale or
+		// error badge instead of a hanging request.
+		badgeMiddleware = append(badgeMiddleware, middleware.ContextTimeout(conf.BadgeTimeout))
+	}
+	// signedMiddleware checks the signature of the other endpoints taking badge
+	// params, which would otherwise let unsigned requests pick any namespace
+	// or selector.
+	var signedMiddleware []echo.MiddlewareFunc
+	if conf.SigningSecret != "" {
+		signedMiddleware = append(signedMiddleware, signatureMiddleware(conf.SigningSecret))
+	}
+	badgeRoute := func(path string, h echo.HandlerFunc) {
+		e.Match(badgeMethods, path, h, badgeMiddleware...)
+		badgePaths[path] = true
+	}
+	for _, evaluator := range enabledEvaluators() {
+		name := evaluator.Name()
+		badgeRoute("/"+name, badgeHandler(name, evaluator.Evaluate))
+		badgeRoute("/clusters/:cluster/"+name, badgeHandler(name, evaluator.Evaluate))
+		if router, ok := evaluator.(Router); ok {
+			for path, h := range router.Routes() {
+				badgeRoute(path, h)
+			}
+		}
+	}
+	e.GET("/clusters", handleClusters)
+	badgeRoute("/badge/:name", handleNamedBadge)
+	if conf.EnableProbes {
+		badgeRoute("/probes/:name", handleProbe)
+	}
+	if conf.EnableHistory {
+		for _, evaluator := range enabledEvaluators() {
+			if evaluator.Name() != "custom" {
+				badgeRoute("/"+evaluator.Name()+"/history", historyHandler(evaluator.Name()))
+			}
+		}
+		badgeRoute("/badge/:name/history", handleNamedHistory)
+		e.GET("/history.json", handleHistoryJSON, signedMiddleware...)
+	}
+	e.GET("/status", handleStatus)
+	if conf.EnableStats {
+		e.GET("/stats", handleStats)
+	}
+	if conf.EnableEvents {
+		e.GET("/events/stream", handleEventStream, signedMiddleware...)
+	}
+	e.POST("/config/reload", handleConfigReload, tokenRequired)
+	e.GET("/maintenance", handleGetMaintenance)
+	e.POST("/maintenance", handleStartMaintenance, tokenRequired)
+	e.DELETE("/maintenance", handleEndMaintenance, tokenRequired)
+	if conf.EnablePods {
+		e.GET("/api/pods", handleAPIPods, signedMiddleware...)
+	}
+	e.POST("/batch", handleBatch)
+	e.GET("/badges", handleBadges, signedMiddleware...)
+	if conf.EnableMetrics {
+		e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+		e.Use(metricsMiddleware)
+	}
+	e.GET("/openapi.json", handleOpenAPI(openAPISpec(e.Routes(), badgePaths)))
+
+	if conf.OTLPEndpoint != "" {
+		e.Use(otelecho.Middleware(TRACING_SERVICE_NAME))
+	}
+	e.Use(middleware.RequestID())
+	e.Use(accessLogMiddleware)
+	e.Use(middleware.Recover())
+	if conf.Gzip {
+		// Event streams are flushed per event, and promhttp compresses itself.
+		e.Use(middleware.GzipWithConfig(middleware.GzipConfig{
+			MinLength: conf.GzipMinLength,
+			Skipper: func(ctx echo.Context) bool {
+				return ctx.Path() == "/events/stream" || ctx.Path() == "/metrics"
+			},
+		}))
+	}
+	if len(conf.CORSOrigins) > 0 {
+		e.Use(corsMiddleware(conf.CORSOrigins))
+	}
+	if conf.RateLimit > 0 {
+		e.Use(rateLimitMiddleware(conf.RateLimit, conf.RateLimitBurst))
+	}
+	if len(conf.AuthTokens) > 0 {
+		e.Use(authMiddleware(conf.AuthTokens))
 	}
+	return e
 }
 
-func newClient(conf *Config) (kubernetes.Interface, error) {
-	var config *rest.Config
-	var err error
-	if conf.Debug {
-		configPath := filepath.Join(homedir.HomeDir(), ".kube", "config")
-		config, err = clientcmd.BuildConfigFromFlags("", configPath)
+// restConfig uses the in-cluster configuration unless a kubeconfig is selected
+// through APP_KUBECONFIG, KUBECONFIG, APP_KUBE_CONTEXT or debug mode, which
+// falls back to ~/.kube/config.
+func restConfig(conf *Config) (*rest.Config, error) {
+	if conf.Kubeconfig == "" && conf.KubeContext == "" && os.Getenv(clientcmd.RecommendedConfigPathEnvVar) == "" && !conf.Debug {
+		return rest.InClusterConfig()
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	// envconfig also fills Kubeconfig from KUBECONFIG, which may list several files.
+	if paths := filepath.SplitList(conf.Kubeconfig); len(paths) > 1 {
+		rules.Precedence = paths
 	} else {
-		config, err = rest.InClusterConfig()
+		rules.ExplicitPath = conf.Kubeconfig
 	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		rules,
+		&clientcmd.ConfigOverrides{CurrentContext: conf.KubeContext},
+	).ClientConfig()
+}
+
+func newClient(conf *Config) (kubernetes.Interface, dynamic.Interface, error) {
+	config, err := restConfig(conf)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	traceClient(config)
+	restConfigs[""] = config
 
 	client, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
 	}
-	return client, nil
+	return client, dynamicClient, nil
 }
 
-func healthz(ctx echo.Context) error {
+// livez only reports that the process is serving; /healthz is kept as an alias.
+func livez(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, "ok")
 }
 
-func handlePods(ctx echo.Context) error {
-	pods, err := k8sClient.CoreV1().Pods("").List(ctx.Request().Context(), v1.ListOptions{})
-	if err != nil {
-		slog.Error(err.Error())
-		return ctx.JSON(http.StatusInternalServerError, err.Error())
+// readyz fails while the API server is unreachable, the informers are not
+// synced or the badge cache is still warming up, so traffic is not routed to an
+// instance that can only serve errors or slow first responses. It optionally
+// also fails until some enabled resource lists at least one object, since an
+// entirely empty cluster usually means a wrong cluster or RBAC scope. The body
+// reports each check, including whether any badge is cached yet.
+func readyz(ctx echo.Context) error {
+	checks := echo.Map{}
+	ready := true
+	fail := func(check string, message any) {
+		checks[check] = message
+		ready = false
+	}
+
+	if err := pingKubernetes(ctx.Request().Context()); err != nil {
+		fail("kubernetes", errorMessage(err))
+	} else {
+		checks["kubernetes"] = "ok"
+	}
+	switch {
+	case informerFactory != nil:
+		checks["informers"] = "synced"
+	case conf.UseInformers:
+		fail("informers", "not synced")
 	}
-	healthyPodsCount := 0
-	for _, pod := range pods.Items {
-		if pod.Status.Phase == "Running" || pod.Status.Phase == "Succeeded" {
-			healthyPodsCount++
+	if warmingUp.Load() {
+		fail("warmup", "in progress")
+	}
+	if draining.Load() {
+		fail("shutdown", "draining")
+	}
+	if conf.ReadyRequireResources && !resourcesFound.Load() {
+		found, err := anyResourcesFound(ctx.Request().Context())
+		switch {
+		case err != nil:
+			fail("resources", errorMessage(err))
+		case !found:
+			fail("resources", "no resources found")
+		default:
+			resourcesFound.Store(true)
 		}
 	}
-	var color string
-	rate := float64(healthyPodsCount) / float64(len(pods.Items))
-	if rate < 0.5 {
-		color = BADGE_COLOR_FATAL
-	} else if rate < 0.8 {
-		color = BADGE_COLOR_WARN
-	} else {
-		color = BADGE_COLOR_HEALTHY
-	}
-	return ctx.JSON(http.StatusOK, echo.Map{
-		"schemaVersion": 1,
-		"label":         fmt.Sprintf("pods(%s)", conf.Env),
-		"message":       fmt.Sprintf("%d/%d", healthyPodsCount, len(pods.Items)),
-		"color":         color,
-	})
+	checks["cache"] = "cold"
+	if anyEvaluated() {
+		checks["cache"] = "warm"
+	}
+
+	if !ready {
+		return ctx.JSON(http.StatusServiceUnavailable, checks)
+	}
+	return ctx.JSON(http.StatusOK, checks)
 }
 
-func handleNodes(ctx echo.Context) error {
-	nodes, err := k8sClient.CoreV1().Nodes().List(ctx.Request().Context(), v1.ListOptions{})
-	if err != nil {
-		slog.Error(err.Error())
-		return ctx.JSON(http.StatusInternalServerError, err.Error())
-	}
-	healthyNodesCount := 0
-	for _, node := range nodes.Items {
-		conditions := node.Status.Conditions
-		if conditions[len(conditions)-1].Status == "True" {
-			healthyNodesCount++
-		}
+// pingKubernetes fetches the server version of the default cluster, a cheap
+// call that needs no RBAC, within APP_READY_TIMEOUT.
+func pingKubernetes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, conf.ReadyTimeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := serverVersion(ctx, listQuery{NoCache: true})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return ctx.JSON(http.StatusOK, echo.Map{
-		"schemaVersion": 1,
-		"label":         fmt.Sprintf("nodes(%s)", conf.Env),
-		"message":       fmt.Sprintf("%d/%d", healthyNodesCount, len(nodes.Items)),
-		"color":         "blue",
-	})
 }
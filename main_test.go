@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kelseyhightower/envconfig"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// setupTest loads the default config, with env overriding it as in main, and
+// serves objects from a fake clientset with empty caches.
+func setupTest(t testing.TB, env map[string]string, objects ...runtime.Object) {
+	t.Helper()
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	*conf = Config{}
+	if err := envconfig.Process("APP", conf); err != nil {
+		t.Fatal(err)
+	}
+	k8sClient = fake.NewSimpleClientset(objects...)
+	listCache = newTTLCache("list")
+	badgeCache = newTTLCache("badge")
+}
+
+// get serves path from a new server over the current config.
+func get(t testing.TB, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	newServer(conf).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+// getMessage serves path and returns the message of the shields JSON.
+func getMessage(t testing.TB, path string) string {
+	t.Helper()
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(get(t, path).Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Message
+}
+
+// versionClient serves discovery from a real client, as the fake one has no
+// REST client to fetch /version with.
+type versionClient struct {
+	*fake.Clientset
+	discovery discovery.DiscoveryInterface
+}
+
+func (c versionClient) Discovery() discovery.DiscoveryInterface {
+	return c.discovery
+}
+
+func withVersionServer(t testing.TB, client *fake.Clientset) kubernetes.Interface {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(version.Info{GitVersion: "v1.31.1"})
+	}))
+	t.Cleanup(server.Close)
+	return versionClient{Clientset: client, discovery: discovery.NewDiscoveryClientForConfigOrDie(&rest.Config{Host: server.URL})}
+}
+
+func TestReadyzEmptyCluster(t *testing.T) {
+	tests := []struct {
+		name    string
+		require string
+		objects []runtime.Object
+		code    int
+	}{
+		{name: "empty", require: "true", code: http.StatusServiceUnavailable},
+		{name: "with a pod", require: "true", objects: []runtime.Object{testPod("web", true, "", 0)}, code: http.StatusOK},
+		{name: "empty without the check", require: "false", code: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, map[string]string{"APP_READY_REQUIRE_RESOURCES": tt.require}, tt.objects...)
+			k8sClient = withVersionServer(t, k8sClient.(*fake.Clientset))
+			resourcesFound.Store(false)
+			rec := get(t, "/readyz")
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			if tt.code != http.StatusOK && !strings.Contains(rec.Body.String(), "no resources found") {
+				t.Errorf("body = %s, want the resources check to fail", rec.Body)
+			}
+		})
+	}
+}
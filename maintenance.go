@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	BADGE_LEVEL_MAINTENANCE = "maintenance"
+	BADGE_COLOR_MAINTENANCE = "lightgrey"
+)
+
+// maintenanceWindow is a recurring window declared in the maintenance section
+// of the APP_CONFIG file, e.g. schedule "CRON_TZ=Asia/Tokyo 0 2 * * 6" with
+// duration "2h" for weekly node drains.
+type maintenanceWindow struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	Duration string `json:"duration"`
+	// Badges limits the window to these badges, e.g. "nodes" or "badge/web";
+	// empty means every badge.
+	Badges []string `json:"badges"`
+
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+func (w *maintenanceWindow) parse() error {
+	schedule, err := cron.ParseStandard(w.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", w.Schedule, err)
+	}
+	duration, err := time.ParseDuration(w.Duration)
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("invalid duration %q", w.Duration)
+	}
+	w.schedule, w.duration = schedule, duration
+	return nil
+}
+
+// active reports whether now falls within an occurrence of the window, i.e.
+// the window started less than its duration ago.
+func (w maintenanceWindow) active(now time.Time) bool {
+	return !w.schedule.Next(now.Add(-w.duration)).After(now)
+}
+
+func coversBadge(badges []string, name string) bool {
+	return len(badges) == 0 || slices.Contains(badges, name)
+}
+
+// maintenanceWindows is swapped together with badgeDefs on reload.
+var maintenanceWindows atomic.Pointer[[]maintenanceWindow]
+
+func init() {
+	maintenanceWindows.Store(&[]maintenanceWindow{})
+}
+
+// manualMaintenance is started and ended through /maintenance. It is kept in
+// memory, so every replica has to be told.
+type manualMaintenance struct {
+	Badges []string `json:"badges"`
+	Reason string   `json:"reason,omitempty"`
+	// Until ends the maintenance on its own; nil lasts until DELETE /maintenance.
+	Until *time.Time `json:"until,omitempty"`
+}
+
+var (
+	manualMaintenanceMu sync.Mutex
+	manual              *manualMaintenance
+)
+
+// inMaintenance reports whether a window or the manual maintenance covers the
+// badge name at now.
+func inMaintenance(name string, now time.Time) bool {
+	for _, w := range *maintenanceWindows.Load() {
+		if coversBadge(w.Badges, name) && w.active(now) {
+			return true
+		}
+	}
+	manualMaintenanceMu.Lock()
+	defer manualMaintenanceMu.Unlock()
+	return manual != nil && (manual.Until == nil || now.Before(*manual.Until)) && coversBadge(manual.Badges, name)
+}
+
+// applyMaintenance greys out unhealthy badges under maintenance, so planned
+// work does not show as an outage.
+func applyMaintenance(b badge) badge {
+	if b.Level == BADGE_LEVEL_HEALTHY || b.Level == "" || !inMaintenance(b.Name, time.Now()) {
+		return b
+	}
+	b.Message = "maintenance"
+	b.Color = BADGE_COLOR_MAINTENANCE
+	b.Level = BADGE_LEVEL_MAINTENANCE
+	return b
+}
+
+func handleGetMaintenance(ctx echo.Context) error {
+	now := time.Now()
+	windows := []echo.Map{}
+	for _, w := range *maintenanceWindows.Load() {
+		windows = append(windows, echo.Map{"name": w.Name, "schedule": w.Schedule, "duration": w.Duration, "badges": w.Badges, "active": w.active(now)})
+	}
+	manualMaintenanceMu.Lock()
+	defer manualMaintenanceMu.Unlock()
+	return ctx.JSON(http.StatusOK, echo.Map{"windows": windows, "manual": manual})
+}
+
+// handleStartMaintenance starts the manual maintenance from a body such as
+// {"badges": ["nodes"], "duration": "1h", "reason": "kernel upgrade"}. Like
+// ending it, this requires one of APP_AUTH_TOKENS.
+func handleStartMaintenance(ctx echo.Context) error {
+	var request struct {
+		Badges   []string `json:"badges"`
+		Duration string   `json:"duration"`
+		Reason   string   `json:"reason"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return respondError(ctx, err)
+	}
+	maintenance := &manualMaintenance{Badges: request.Badges, Reason: request.Reason}
+	if request.Duration != "" {
+		duration, err := time.ParseDuration(request.Duration)
+		if err != nil || duration <= 0 {
+			return respondError(ctx, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid duration: %s", request.Duration)))
+		}
+		until := time.Now().Add(duration)
+		maintenance.Until = &until
+	}
+	manualMaintenanceMu.Lock()
+	manual = maintenance
+	manualMaintenanceMu.Unlock()
+	return ctx.JSON(http.StatusOK, maintenance)
+}
+
+func handleEndMaintenance(ctx echo.Context) error {
+	manualMaintenanceMu.Lock()
+	manual = nil
+	manualMaintenanceMu.Unlock()
+	return ctx.NoContent(http.StatusNoContent)
+}
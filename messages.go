@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// messageData is what a badge definition's message template renders, e.g.
+// "{{.Healthy}}/{{.Total}} healthy ({{.Percent}}%)" or "{{.Unhealthy}} nicht bereit".
+type messageData struct {
+	Label string
+	// Message is the message the resource computed, such as "3/4, 1 stuck".
+	Message   string
+	Color     string
+	Level     string
+	Healthy   int
+	Unhealthy int
+	Total     int
+	Critical  int
+	// Percent is the healthy share rounded down, so it only reaches 100 when
+	// everything is healthy; 100 without objects.
+	Percent int
+	Items   []itemStatus
+}
+
+func newMessageData(b badge) messageData {
+	percent := 100
+	if b.Count.Total > 0 {
+		percent = b.Count.Healthy * 100 / b.Count.Total
+	}
+	return messageData{
+		Label:     b.Label,
+		Message:   b.Message,
+		Color:     b.Color,
+		Level:     colorLevels[b.Color],
+		Healthy:   b.Count.Healthy,
+		Unhealthy: b.Count.Total - b.Count.Healthy,
+		Total:     b.Count.Total,
+		Critical:  b.Count.Critical,
+		Percent:   percent,
+		Items:     b.Count.Items,
+	}
+}
+
+// parseMessageTemplate parses text and renders it once over an empty result,
+// so references to unknown fields fail when the config is loaded.
+func parseMessageTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := renderMessage(tmpl, badge{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+func renderMessage(tmpl *template.Template, b badge) (string, error) {
+	var message strings.Builder
+	if err := tmpl.Execute(&message, newMessageData(b)); err != nil {
+		return "", fmt.Errorf("message template: %w", err)
+	}
+	return message.String(), nil
+}
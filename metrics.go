@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const METRICS_NAMESPACE = "k8s_status_badge"
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "requests_total",
+		Help:      "HTTP requests by route and status code.",
+	}, []string{"route", "code"})
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route"})
+	kubernetesErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "kubernetes_api_errors_total",
+		Help:      "Failed Kubernetes API list calls by resource and cluster.",
+	}, []string{"resource", "cluster"})
+	cacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+	badgeHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "badge_healthy",
+		Help:      "Healthy objects counted by the last computation of each badge.",
+	}, []string{"badge"})
+	badgeTotal = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "badge_total",
+		Help:      "Objects counted by the last computation of each badge.",
+	}, []string{"badge"})
+)
+
+func metricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		start := time.Now()
+		err := next(ctx)
+		if err != nil {
+			ctx.Error(err)
+		}
+		route := ctx.Path()
+		requestsTotal.WithLabelValues(route, strconv.Itoa(ctx.Response().Status)).Inc()
+		requestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+		return nil
+	}
+}
+
+func recordBadge(name string, b badge) {
+	badgeHealthy.WithLabelValues(name).Set(float64(b.Count.Healthy))
+	badgeTotal.WithLabelValues(name).Set(float64(b.Count.Total))
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "nodes",
+			enabled: func() bool { return conf.EnableNodes },
+			badge:   nodesBadge,
+			routes: map[string]echo.HandlerFunc{
+				"/nodes/:name":        handleNode,
+				"/nodes/versions":     handleNodeVersions,
+				"/drain-safety/:node": handleDrainSafety,
+			},
+		},
+		count: countNodes,
+	})
+}
+
+func countNodes(ctx context.Context, params url.Values) (healthCount, error) {
+	summary, err := evaluateNodes(ctx, params)
+	return summary.Count, err
+}
+
+// nodeSummary breaks ready nodes down by conditions that limit scheduling.
+type nodeSummary struct {
+	Count     healthCount
+	Pressured int
+	Cordoned  int
+	// Tainted counts ready, uncordoned nodes with a NoSchedule taint.
+	Tainted int
+}
+
+// nodeLifecycleTaints are added by Kubernetes itself for cordoned or NotReady
+// nodes, which are already reported on their own.
+var nodeLifecycleTaints = map[string]bool{
+	corev1.TaintNodeUnschedulable: true,
+	corev1.TaintNodeNotReady:      true,
+	corev1.TaintNodeUnreachable:   true,
+}
+
+func hasNoScheduleTaint(node *corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectNoSchedule && !nodeLifecycleTaints[taint.Key] {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateNodes counts ready nodes as healthy. Cordoned nodes only count as
+// unhealthy with APP_CORDONED_UNHEALTHY or ?cordonedUnhealthy=true.
+func evaluateNodes(ctx context.Context, params url.Values) (nodeSummary, error) {
+	cordonedUnhealthy := conf.CordonedUnhealthy
+	if value := params.Get("cordonedUnhealthy"); value != "" {
+		cordonedUnhealthy = value == "true"
+	}
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return nodeSummary{}, err
+	}
+	nodes, err := listNodes(ctx, q)
+	if err != nil {
+		return nodeSummary{}, err
+	}
+	summary := nodeSummary{}
+	for _, node := range nodes {
+		if !isNodeReady(node) {
+			summary.Count.add(node, false)
+			continue
+		}
+		if node.Spec.Unschedulable {
+			summary.Cordoned++
+			if cordonedUnhealthy {
+				summary.Count.add(node, false)
+				continue
+			}
+		} else if hasNoScheduleTaint(node) {
+			summary.Tainted++
+		}
+		summary.Count.add(node, true)
+		if hasNodePressure(node) {
+			summary.Pressured++
+		}
+	}
+	return summary, nil
+}
+
+func hasNodePressure(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		switch condition.Type {
+		case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure:
+			if condition.Status == corev1.ConditionTrue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isNodeReady treats a node as ready while its Ready condition is True, or while it
+// left True less than conf.NodeFlapGrace ago (e.g. during a kubelet restart).
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type != corev1.NodeReady {
+			continue
+		}
+		if condition.Status == corev1.ConditionTrue {
+			return true
+		}
+		return conf.NodeFlapGrace > 0 && time.Since(condition.LastTransitionTime.Time) < conf.NodeFlapGrace
+	}
+	return false
+}
+
+func listNotReadyNodes(ctx context.Context, q listQuery) (map[string]bool, error) {
+	nodes, err := listNodes(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	notReady := map[string]bool{}
+	for _, node := range nodes {
+		if !isNodeReady(node) {
+			notReady[node.Name] = true
+		}
+	}
+	return notReady, nil
+}
+
+// weightedNodeRate returns the share of allocatable capacity (conf.NodeWeightResource)
+// provided by Ready nodes, so losing a large node weighs more than losing a small one.
+// The plain node count is returned alongside.
+func weightedNodeRate(ctx context.Context, params url.Values) (float64, healthCount, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return 0, healthCount{}, err
+	}
+	nodes, err := listNodes(ctx, q)
+	if err != nil {
+		return 0, healthCount{}, err
+	}
+	count := healthCount{}
+	var ready, total int64
+	for _, node := range nodes {
+		allocatable := node.Status.Allocatable[corev1.ResourceName(conf.NodeWeightResource)]
+		total += allocatable.MilliValue()
+		healthy := isNodeReady(node)
+		if healthy {
+			ready += allocatable.MilliValue()
+		}
+		count.add(node, healthy)
+	}
+	if total == 0 {
+		return 1, count, nil
+	}
+	return float64(ready) / float64(total), count, nil
+}
+
+func nodesBadge(ctx context.Context, params url.Values) (badge, error) {
+	switch mode := params.Get("mode"); mode {
+	case "":
+	case "weighted":
+		rate, count, err := weightedNodeRate(ctx, params)
+		if err != nil {
+			return badge{}, err
+		}
+		return badge{
+			Label:   badgeLabel("nodes", params),
+			Message: fmt.Sprintf("%.0f%%", rate*100),
+			Color:   rateColor(rate, params),
+			Count:   count,
+		}, nil
+	default:
+		return badge{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown mode: %s", mode))
+	}
+	summary, err := evaluateNodes(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := countBadge(badgeLabel("nodes", params), summary.Count, params)
+	b.Message += " ready"
+	if summary.Cordoned > 0 {
+		b.Message += fmt.Sprintf(", %d cordoned", summary.Cordoned)
+	}
+	if summary.Tainted > 0 {
+		b.Message += fmt.Sprintf(", %d tainted", summary.Tainted)
+	}
+	if summary.Pressured > 0 {
+		b.Message += fmt.Sprintf(", %d under pressure", summary.Pressured)
+		if b.Color == BADGE_COLOR_HEALTHY {
+			b.Color = BADGE_COLOR_WARN
+		}
+	}
+	return b, nil
+}
+
+// nodeBadge reports the Ready condition and kubelet version of a single node.
+func nodeBadge(name string) badgeFunc {
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		q, err := newListQuery(ctx, params)
+		if err != nil {
+			return badge{}, err
+		}
+		node, err := getNode(ctx, q, name)
+		if apierrors.IsNotFound(err) {
+			return badge{}, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("node %s not found", name))
+		}
+		recordClusterCall(q.Cluster, err)
+		if err != nil {
+			kubernetesErrorsTotal.WithLabelValues("nodes", q.Cluster).Inc()
+			return badge{}, err
+		}
+		b := badge{Label: name, Message: "NotReady", Color: BADGE_COLOR_FATAL, Count: healthCount{Total: 1}}
+		if isNodeReady(node) {
+			b.Message, b.Color, b.Count.Healthy = "Ready", BADGE_COLOR_HEALTHY, 1
+			if hasNodePressure(node) {
+				b.Message += ", under pressure"
+				b.Color = BADGE_COLOR_WARN
+			}
+		}
+		if version := node.Status.NodeInfo.KubeletVersion; version != "" {
+			b.Message += ", " + version
+		}
+		return b, nil
+	}
+}
+
+func handleNode(ctx echo.Context) error {
+	name := ctx.Param("name")
+	return serveBadge(ctx, "nodes/"+name, ctx.QueryParams(), nodeBadge(name))
+}
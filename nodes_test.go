@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testNodeCPU(name string, ready bool, cpu string) *corev1.Node {
+	node := testNode(name, ready)
+	node.Status.Allocatable = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+	return node
+}
+
+func TestNodesBadgeWeighted(t *testing.T) {
+	nodes := []runtime.Object{testNodeCPU("large", true, "8"), testNodeCPU("small", false, "2")}
+	setupTest(t, map[string]string{"APP_ENV": "prod"}, nodes...)
+	defer func(previous map[string]kubernetes.Interface) { clusters = previous }(clusters)
+	clusters = map[string]kubernetes.Interface{"edge": fake.NewSimpleClientset(nodes...)}
+	tests := []struct {
+		params url.Values
+		label  string
+	}{
+		{params: url.Values{"mode": {"weighted"}}, label: "nodes(prod)"},
+		{params: url.Values{"mode": {"weighted"}, "cluster": {"edge"}}, label: "nodes(edge)"},
+	}
+	for _, tt := range tests {
+		b, err := nodesBadge(context.Background(), tt.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Label != tt.label || b.Message != "80%" || b.Count.Healthy != 1 || b.Count.Total != 2 {
+			t.Errorf("nodesBadge(%v) = %s %s %d/%d, want %s 80%% 1/2", tt.params, b.Label, b.Message, b.Count.Healthy, b.Count.Total, tt.label)
+		}
+	}
+}
+
+func TestIsNodeReadyFlapGrace(t *testing.T) {
+	notReadySince := func(ago time.Duration) *corev1.Node {
+		node := testNode("n1", false)
+		node.Status.Conditions[0].LastTransitionTime = v1.NewTime(time.Now().Add(-ago))
+		return node
+	}
+	tests := []struct {
+		name  string
+		grace time.Duration
+		node  *corev1.Node
+		want  bool
+	}{
+		{name: "ready", grace: time.Minute, node: testNode("n1", true), want: true},
+		{name: "within grace", grace: time.Minute, node: notReadySince(10 * time.Second), want: true},
+		{name: "past grace", grace: time.Minute, node: notReadySince(2 * time.Minute), want: false},
+		{name: "without grace", grace: 0, node: notReadySince(time.Second), want: false},
+		{name: "no Ready condition", grace: time.Minute, node: &corev1.Node{}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, nil)
+			conf.NodeFlapGrace = tt.grace
+			if got := isNodeReady(tt.node); got != tt.want {
+				t.Errorf("isNodeReady = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
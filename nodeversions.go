@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nodeVersionFields are the node info fields a rolling upgrade changes.
+var nodeVersionFields = []struct {
+	name  string
+	value func(info corev1.NodeSystemInfo) string
+}{
+	{"kubelet", func(info corev1.NodeSystemInfo) string { return info.KubeletVersion }},
+	{"runtime", func(info corev1.NodeSystemInfo) string { return info.ContainerRuntimeVersion }},
+	{"os", func(info corev1.NodeSystemInfo) string { return info.OSImage }},
+	{"kernel", func(info corev1.NodeSystemInfo) string { return info.KernelVersion }},
+}
+
+var (
+	deviatingSinceMu sync.Mutex
+	// deviatingSince is when each node was first seen off the most common
+	// versions, keyed by cluster and node. It is kept in memory, so a restart
+	// starts the clock again.
+	deviatingSince = map[string]time.Time{}
+)
+
+// mostCommon returns the most frequent value, preferring the larger one on a
+// tie so a half-finished upgrade counts the old nodes as deviating.
+func mostCommon(counts map[string]int) string {
+	best := ""
+	for value, count := range counts {
+		if count > counts[best] || (count == counts[best] && value > best) {
+			best = value
+		}
+	}
+	return best
+}
+
+// nodeVersionsBadge counts the nodes running the most common kubelet,
+// container runtime, OS image and kernel. It turns yellow while nodes deviate,
+// as during a rolling upgrade, and red once a node has deviated for longer than
+// APP_NODE_VERSION_MAX_DEVIATION.
+func nodeVersionsBadge(ctx context.Context, params url.Values) (badge, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	nodes, err := listNodes(ctx, q)
+	if err != nil {
+		return badge{}, err
+	}
+	common := make([]string, len(nodeVersionFields))
+	for i, field := range nodeVersionFields {
+		counts := map[string]int{}
+		for _, node := range nodes {
+			counts[field.value(node.Status.NodeInfo)]++
+		}
+		common[i] = mostCommon(counts)
+	}
+	now := time.Now()
+	count := healthCount{}
+	deviating := map[string]int{}
+	stuck := 0
+	deviatingSinceMu.Lock()
+	for _, node := range nodes {
+		key := q.Cluster + "/" + node.Name
+		uniform := true
+		for i, field := range nodeVersionFields {
+			if field.value(node.Status.NodeInfo) != common[i] {
+				deviating[field.name]++
+				uniform = false
+			}
+		}
+		count.add(node, uniform)
+		if uniform {
+			delete(deviatingSince, key)
+			continue
+		}
+		since, seen := deviatingSince[key]
+		if !seen {
+			since = now
+			deviatingSince[key] = now
+		}
+		if now.Sub(since) > conf.NodeVersionMaxDeviation {
+			stuck++
+		}
+	}
+	deviatingSinceMu.Unlock()
+
+	b := badge{Label: badgeLabel("node versions", params), Color: BADGE_COLOR_HEALTHY, Count: count, Empty: count.Total == 0}
+	b.Message = fmt.Sprintf("%d/%d on %s", count.Healthy, count.Total, common[0])
+	var deviations []string
+	for _, field := range nodeVersionFields {
+		if deviating[field.name] > 0 {
+			deviations = append(deviations, fmt.Sprintf("%d %s", deviating[field.name], field.name))
+		}
+	}
+	if len(deviations) > 0 {
+		b.Message += ", deviating: " + strings.Join(deviations, ", ")
+	}
+	switch {
+	case stuck > 0:
+		b.Color = BADGE_COLOR_FATAL
+		b.Message += fmt.Sprintf(", %d stuck", stuck)
+	case count.Healthy < count.Total:
+		b.Color = BADGE_COLOR_WARN
+	}
+	return b, nil
+}
+
+func handleNodeVersions(ctx echo.Context) error {
+	return serveBadge(ctx, "nodes/versions", ctx.QueryParams(), nodeVersionsBadge)
+}
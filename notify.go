@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	BADGE_LEVEL_HEALTHY = "healthy"
+	BADGE_LEVEL_WARN    = "warn"
+	BADGE_LEVEL_FATAL   = "fatal"
+)
+
+var colorLevels = map[string]string{
+	BADGE_COLOR_HEALTHY: BADGE_LEVEL_HEALTHY,
+	BADGE_COLOR_WARN:    BADGE_LEVEL_WARN,
+	BADGE_COLOR_FATAL:   BADGE_LEVEL_FATAL,
+}
+
+// badgeTransition is the payload posted to generic webhooks when a badge
+// moves between levels.
+type badgeTransition struct {
+	Badge    string    `json:"badge"`
+	Label    string    `json:"label"`
+	Message  string    `json:"message"`
+	Level    string    `json:"level"`
+	Previous string    `json:"previous"`
+	Healthy  int       `json:"healthy"`
+	Total    int       `json:"total"`
+	Time     time.Time `json:"time"`
+}
+
+var chatTemplate = template.Must(template.New("chat").Parse(
+	`{{if eq .Level "fatal"}}:red_circle:{{else if eq .Level "warn"}}:warning:{{else}}:white_check_mark:{{end}} {{.Label}} is {{.Level}}: {{.Message}} (was {{.Previous}})`,
+))
+
+type webhook struct {
+	url string
+	// body wraps the chat text for Slack and Discord; nil posts the transition as is.
+	body func(text string) any
+}
+
+func configuredWebhooks() []webhook {
+	var hooks []webhook
+	for _, url := range conf.WebhookURLs {
+		hooks = append(hooks, webhook{url: url})
+	}
+	for _, url := range conf.SlackWebhookURLs {
+		hooks = append(hooks, webhook{url: url, body: func(text string) any { return map[string]string{"text": text} }})
+	}
+	for _, url := range conf.DiscordWebhookURLs {
+		hooks = append(hooks, webhook{url: url, body: func(text string) any { return map[string]string{"content": text} }})
+	}
+	return hooks
+}
+
+// levelState tracks the last notified level of a badge and a candidate level
+// that has to hold for APP_WEBHOOK_DEBOUNCE before it is notified.
+type levelState struct {
+	notified     string
+	pending      string
+	pendingSince time.Time
+	lastSent     time.Time
+	// latest is the last evaluation at the pending level.
+	latest badge
+}
+
+// notify builds the transition of name to the pending level and records it
+// as notified.
+func (s *levelState) notify(name string, now time.Time) badgeTransition {
+	transition := badgeTransition{
+		Badge:    name,
+		Label:    s.latest.Label,
+		Message:  s.latest.Message,
+		Level:    s.pending,
+		Previous: s.notified,
+		Healthy:  s.latest.Count.Healthy,
+		Total:    s.latest.Count.Total,
+		Time:     now,
+	}
+	s.notified = s.pending
+	s.pending = ""
+	s.lastSent = now
+	return transition
+}
+
+var (
+	levelStatesMu sync.Mutex
+	levelStates   = map[string]*levelState{}
+)
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// notifyTransition posts to the configured webhooks once the level of b has
+// changed for at least APP_WEBHOOK_DEBOUNCE and APP_WEBHOOK_COOLDOWN has passed
+// since the badge was last notified. The first evaluation only records the level,
+// and badges under maintenance are not notified until it ends.
+func notifyTransition(name string, b badge) {
+	hooks := configuredWebhooks()
+	if len(hooks) == 0 || b.Level == "" || b.Level == BADGE_LEVEL_MAINTENANCE {
+		return
+	}
+	levelStatesMu.Lock()
+	if !leading.Load() {
+		// Track the level so a new leader does not notify transitions
+		// already notified by the previous one.
+		levelStates[name] = &levelState{notified: b.Level}
+		levelStatesMu.Unlock()
+		return
+	}
+	state, seen := levelStates[name]
+	if !seen {
+		levelStates[name] = &levelState{notified: b.Level}
+		levelStatesMu.Unlock()
+		return
+	}
+	now := time.Now()
+	switch {
+	case b.Level == state.notified:
+		state.pending = ""
+	case b.Level != state.pending:
+		state.pending = b.Level
+		state.pendingSince = now
+	}
+	state.latest = b
+	if state.pending == "" || now.Sub(state.pendingSince) < conf.WebhookDebounce || now.Sub(state.lastSent) < conf.WebhookCooldown {
+		levelStatesMu.Unlock()
+		return
+	}
+	transition := state.notify(name, now)
+	levelStatesMu.Unlock()
+	postTransition(hooks, transition)
+}
+
+// flushTransitions notifies the transitions still waiting out
+// APP_WEBHOOK_DEBOUNCE or APP_WEBHOOK_COOLDOWN at once, so shutting down does
+// not drop them.
+func flushTransitions() {
+	hooks := configuredWebhooks()
+	if len(hooks) == 0 || !leading.Load() {
+		return
+	}
+	now := time.Now()
+	var transitions []badgeTransition
+	levelStatesMu.Lock()
+	for name, state := range levelStates {
+		if state.pending != "" {
+			transitions = append(transitions, state.notify(name, now))
+		}
+	}
+	levelStatesMu.Unlock()
+	for _, transition := range transitions {
+		postTransition(hooks, transition)
+	}
+}
+
+// postTransition posts transition to every hook in the background, tracked by
+// webhooksInFlight.
+func postTransition(hooks []webhook, transition badgeTransition) {
+	var text bytes.Buffer
+	if err := chatTemplate.Execute(&text, transition); err != nil {
+		slog.Error("webhook template failed", "error", err.Error())
+		return
+	}
+	for _, hook := range hooks {
+		var payload any = transition
+		if hook.body != nil {
+			payload = hook.body(text.String())
+		}
+		webhooksInFlight.Add(1)
+		go func() {
+			defer webhooksInFlight.Done()
+			postWebhook(hook.url, payload)
+		}()
+	}
+}
+
+func postWebhook(url string, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("webhook payload failed", "error", err.Error())
+		return
+	}
+	res, err := webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Error("webhook failed", "error", err.Error())
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		slog.Error("webhook rejected", "status", res.StatusCode)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestFlushTransitions(t *testing.T) {
+	var mu sync.Mutex
+	var posted []badgeTransition
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var transition badgeTransition
+		json.NewDecoder(r.Body).Decode(&transition)
+		mu.Lock()
+		posted = append(posted, transition)
+		mu.Unlock()
+	}))
+	defer server.Close()
+	setupTest(t, map[string]string{"APP_WEBHOOK_URLS": server.URL, "APP_WEBHOOK_DEBOUNCE": "1h"})
+	clear(levelStates)
+
+	notifyTransition("pods", badge{Label: "pods(prod)", Message: "3/3", Level: BADGE_LEVEL_HEALTHY})
+	notifyTransition("pods", badge{Label: "pods(prod)", Message: "1/3", Level: BADGE_LEVEL_FATAL})
+	notifyTransition("nodes", badge{Label: "nodes(prod)", Message: "2/2 ready", Level: BADGE_LEVEL_HEALTHY})
+	waitWebhooks(context.Background())
+	if len(posted) != 0 {
+		t.Fatalf("posted %v within the debounce", posted)
+	}
+
+	flushTransitions()
+	waitWebhooks(context.Background())
+	if len(posted) != 1 {
+		t.Fatalf("posted %d transitions, want 1", len(posted))
+	}
+	if got := posted[0]; got.Badge != "pods" || got.Level != BADGE_LEVEL_FATAL || got.Previous != BADGE_LEVEL_HEALTHY || got.Message != "1/3" {
+		t.Errorf("posted %+v, want pods from healthy to fatal", got)
+	}
+	flushTransitions()
+	waitWebhooks(context.Background())
+	if len(posted) != 1 {
+		t.Errorf("a second flush posted %d transitions", len(posted)-1)
+	}
+}
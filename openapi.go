@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type openAPIParameter struct {
+	Name        string
+	Description string
+}
+
+// badgeParameters are the query parameters understood by every badge route.
+var badgeParameters = []openAPIParameter{
+	{"namespace", "Comma-separated namespaces to include; empty means all namespaces."},
+	{"selector", "Label selector restricting the listed objects."},
+	{"fieldSelector", `Field selector passed to the API server, e.g. "spec.nodeName=worker-3" or "status.phase!=Pending".`},
+	{"annotation", `Only count objects carrying the annotation, as "key" or "key=value".`},
+	{"profile", "Pod badges: name of a configured health profile replacing the default pod health rules."},
+	{"cluster", "Name of a configured cluster to query instead of the default one."},
+	{"nocache", `Bypass the caches when "true".`},
+	{"warnThreshold", "Healthy ratio below which the badge turns to the warn color."},
+	{"fatalThreshold", "Healthy ratio below which the badge turns to the fatal color."},
+	{"empty", `How a badge matching no objects is shown: "unknown" (grey), "healthy" or "error".`},
+	{"emptyMessage", `Message of a badge matching no objects; defaults to "no <kind>".`},
+	{"label", "Overrides the badge label."},
+	{"healthyColor", "Overrides the healthy color."},
+	{"warnColor", "Overrides the warn color."},
+	{"fatalColor", "Overrides the fatal color."},
+	{"style", "shields.io style of the badge."},
+	{"logo", "shields.io named logo of the badge; replaces the APP_BADGE_LOGO_SVG logo."},
+	{"logoColor", "shields.io color of the logo."},
+	{"labelColor", "Background color of the label; defaults to APP_BADGE_LABEL_COLOR."},
+	{"cacheSeconds", "How long shields.io may cache the badge; defaults to APP_BADGE_CACHE_SECONDS."},
+	{"format", `Response format: "svg", "json" (raw counts, items and any breakdown by cause such as the registries of imagepull), "prom" (text exposition), "text" (the message alone) or "gitlab" (a GitLab project badge with image and link URLs); defaults to the shields endpoint schema.`},
+	{"link", `Link URL of ?format=gitlab, defaulting to APP_BADGE_LINK_URL or the badge's raw JSON, and of ?format=svg, defaulting to APP_BADGE_LINK_URL.`},
+}
+
+var pathParamPattern = regexp.MustCompile(`:([^/]+)`)
+
+// openAPISpec describes routes as an OpenAPI 3 document. Paths in badgePaths
+// get the badge query parameters and the shields endpoint response schema.
+func openAPISpec(routes []*echo.Route, badgePaths map[string]bool) echo.Map {
+	paths := map[string]echo.Map{}
+	for _, route := range routes {
+		path := pathParamPattern.ReplaceAllString(route.Path, "{$1}")
+		operation := echo.Map{
+			"responses": echo.Map{"200": echo.Map{"description": "OK"}},
+		}
+		var parameters []echo.Map
+		for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			parameters = append(parameters, echo.Map{
+				"name": match[1], "in": "path", "required": true, "schema": echo.Map{"type": "string"},
+			})
+		}
+		if badgePaths[route.Path] {
+			for _, p := range badgeParameters {
+				parameters = append(parameters, echo.Map{
+					"name": p.Name, "in": "query", "description": p.Description, "schema": echo.Map{"type": "string"},
+				})
+			}
+			operation["responses"] = echo.Map{
+				"200": echo.Map{
+					"description": "Badge in the shields.io endpoint schema; errors are reported as badges with isError set.",
+					"content": echo.Map{
+						echo.MIMEApplicationJSON: echo.Map{"schema": echo.Map{"$ref": "#/components/schemas/Badge"}},
+						"image/svg+xml":          echo.Map{"schema": echo.Map{"type": "string"}},
+					},
+				},
+				"304": echo.Map{"description": "The badge matches If-None-Match."},
+			}
+		}
+		if parameters != nil {
+			operation["parameters"] = parameters
+		}
+		if paths[path] == nil {
+			paths[path] = echo.Map{}
+		}
+		paths[path][strings.ToLower(route.Method)] = operation
+	}
+
+	spec := echo.Map{
+		"openapi": "3.0.3",
+		"info":    echo.Map{"title": "k8s-status-badge", "version": "1"},
+		"paths":   paths,
+		"components": echo.Map{
+			"schemas": echo.Map{
+				"Badge": echo.Map{
+					"type":     "object",
+					"required": []string{"schemaVersion", conf.BadgeLabelField, conf.BadgeMessageField},
+					"properties": echo.Map{
+						"schemaVersion":        echo.Map{"type": "integer", "enum": []int{1}},
+						conf.BadgeLabelField:   echo.Map{"type": "string"},
+						conf.BadgeMessageField: echo.Map{"type": "string"},
+						conf.BadgeColorField:   echo.Map{"type": "string"},
+						"style":                echo.Map{"type": "string"},
+						"namedLogo":            echo.Map{"type": "string"},
+						"logoSvg":              echo.Map{"type": "string"},
+						"logoColor":            echo.Map{"type": "string"},
+						"labelColor":           echo.Map{"type": "string"},
+						"cacheSeconds":         echo.Map{"type": "integer"},
+						"isError":              echo.Map{"type": "boolean"},
+					},
+				},
+			},
+		},
+	}
+	if len(conf.AuthTokens) > 0 {
+		spec["components"].(echo.Map)["securitySchemes"] = echo.Map{
+			"bearer": echo.Map{"type": "http", "scheme": "bearer"},
+			"token":  echo.Map{"type": "apiKey", "in": "query", "name": "token"},
+		}
+		spec["security"] = []echo.Map{{"bearer": []string{}}, {"token": []string{}}}
+	}
+	return spec
+}
+
+func handleOpenAPI(spec echo.Map) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		return ctx.JSON(http.StatusOK, spec)
+	}
+}
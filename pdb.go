@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "pdb",
+			enabled: func() bool { return conf.EnablePDB },
+			badge:   pdbBadge,
+		},
+		count: countPDBs,
+	})
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "pdbs",
+			enabled: func() bool { return conf.EnablePDB },
+			badge:   pdbsBadge,
+		},
+		count: countPDBCompliance,
+	})
+}
+
+func countPDBs(ctx context.Context, params url.Values) (healthCount, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	pdbs, err := listPDBs(ctx, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	annotation := params.Get("annotation")
+	count := healthCount{}
+	for _, pdb := range pdbs {
+		if !matchAnnotation(pdb, annotation) {
+			continue
+		}
+		count.add(pdb, pdb.Status.CurrentHealthy >= pdb.Status.DesiredHealthy)
+	}
+	return count, nil
+}
+
+func pdbBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countPDBs(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	return countBadge(badgeLabel("pdb", params), count, params), nil
+}
+
+// evaluatePDBCompliance counts PDBs that currently allow a disruption as
+// healthy and tallies the violated ones (currentHealthy < desiredHealthy).
+func evaluatePDBCompliance(ctx context.Context, params url.Values) (count healthCount, violated int, err error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, 0, err
+	}
+	pdbs, err := listPDBs(ctx, q)
+	if err != nil {
+		return healthCount{}, 0, err
+	}
+	annotation := params.Get("annotation")
+	for _, pdb := range pdbs {
+		if !matchAnnotation(pdb, annotation) {
+			continue
+		}
+		count.add(pdb, pdb.Status.DisruptionsAllowed > 0)
+		if pdb.Status.CurrentHealthy < pdb.Status.DesiredHealthy {
+			violated++
+		}
+	}
+	return count, violated, nil
+}
+
+func countPDBCompliance(ctx context.Context, params url.Values) (healthCount, error) {
+	count, _, err := evaluatePDBCompliance(ctx, params)
+	return count, err
+}
+
+func pdbsBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, violated, err := evaluatePDBCompliance(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := countBadge(badgeLabel("pdbs", params), count, params)
+	if violated > 0 {
+		b.Message += fmt.Sprintf(", %d violated", violated)
+		b.Color = BADGE_COLOR_FATAL
+	}
+	return b, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	policyv1 "k8s.io/api/policy/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPDB(name string, currentHealthy, desiredHealthy, disruptionsAllowed int32) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			CurrentHealthy:     currentHealthy,
+			DesiredHealthy:     desiredHealthy,
+			DisruptionsAllowed: disruptionsAllowed,
+		},
+	}
+}
+
+func TestPDBBadgesViolated(t *testing.T) {
+	setupTest(t, nil,
+		testPDB("web", 3, 2, 1),
+		// At its minimum: healthy for /pdb, but allows no disruption.
+		testPDB("api", 2, 2, 0),
+		testPDB("db", 1, 2, 0),
+	)
+	for _, tt := range []struct{ path, want string }{
+		{path: "/pdb", want: "2/3"},
+		{path: "/pdbs", want: "1/3, 1 violated"},
+	} {
+		if got := getMessage(t, tt.path); got != tt.want {
+			t.Errorf("%s: message = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "pending",
+			enabled: func() bool { return conf.EnablePending },
+			badge:   pendingBadge,
+		},
+		count: countPendingPods,
+	})
+}
+
+// pendingSummary describes the pods pending for longer than the threshold.
+type pendingSummary struct {
+	count         healthCount
+	threshold     time.Duration
+	unschedulable int
+	oldest        time.Duration
+}
+
+func isUnschedulable(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			return condition.Reason == corev1.PodReasonUnschedulable
+		}
+	}
+	return false
+}
+
+// evaluatePending counts pods that have not been Pending for longer than
+// ?threshold= (APP_PENDING_THRESHOLD) as healthy.
+func evaluatePending(ctx context.Context, params url.Values) (pendingSummary, error) {
+	summary := pendingSummary{threshold: conf.PendingThreshold}
+	if value := params.Get("threshold"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			return pendingSummary{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid threshold: %s", value))
+		}
+		summary.threshold = parsed
+	}
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return pendingSummary{}, err
+	}
+	pods, err := listPods(ctx, q)
+	if err != nil {
+		return pendingSummary{}, err
+	}
+	now := time.Now()
+	for _, pod := range filterPods(pods, params) {
+		age := now.Sub(pod.CreationTimestamp.Time)
+		stuck := pod.Status.Phase == corev1.PodPending && age > summary.threshold
+		summary.count.add(pod, !stuck)
+		if !stuck {
+			continue
+		}
+		if isUnschedulable(pod) {
+			summary.unschedulable++
+		}
+		summary.oldest = max(summary.oldest, age)
+	}
+	return summary, nil
+}
+
+func countPendingPods(ctx context.Context, params url.Values) (healthCount, error) {
+	summary, err := evaluatePending(ctx, params)
+	return summary.count, err
+}
+
+// pendingBadge reports e.g. "2 pending > 10m (1 unschedulable), oldest 1h5m",
+// red while any pod is stuck.
+func pendingBadge(ctx context.Context, params url.Values) (badge, error) {
+	summary, err := evaluatePending(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	stuck := summary.count.Total - summary.count.Healthy
+	message := fmt.Sprintf("%d pending > %s", stuck, shortDuration(summary.threshold))
+	color := BADGE_COLOR_HEALTHY
+	if stuck > 0 {
+		color = BADGE_COLOR_FATAL
+		if summary.unschedulable > 0 {
+			message += fmt.Sprintf(" (%d unschedulable)", summary.unschedulable)
+		}
+		oldest := summary.oldest.Truncate(time.Second)
+		if oldest >= time.Minute {
+			oldest = oldest.Truncate(time.Minute)
+		}
+		message += ", oldest " + shortDuration(oldest)
+	}
+	return badge{
+		Label:   badgeLabel("pending", params),
+		Message: message,
+		Color:   color,
+		Count:   summary.count,
+	}, nil
+}
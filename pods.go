@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "pods",
+			enabled: func() bool { return conf.EnablePods },
+			badge:   podsBadge,
+			routes: map[string]echo.HandlerFunc{
+				"/pods/:namespace/:name": handlePod,
+			},
+		},
+		count: countPods,
+	})
+}
+
+func containerStatuses(pod *corev1.Pod) []corev1.ContainerStatus {
+	statuses := make([]corev1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	return append(statuses, pod.Status.ContainerStatuses...)
+}
+
+var failingWaitingReasons = map[string]bool{
+	"CrashLoopBackOff": true,
+	"ImagePullBackOff": true,
+	"ErrImagePull":     true,
+}
+
+// isPodHealthy treats completed pods as healthy and running pods as healthy only
+// when they are Ready and no container is stuck in a failing waiting state, since
+// a pod stays Running while its containers crashloop.
+func isPodHealthy(pod *corev1.Pod) bool {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return true
+	case corev1.PodRunning:
+	default:
+		return false
+	}
+	if hasFailingContainer(pod) {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// hasFailingContainer reports whether a container waits in one of failingWaitingReasons.
+func hasFailingContainer(pod *corev1.Pod) bool {
+	for _, status := range containerStatuses(pod) {
+		if status.State.Waiting != nil && failingWaitingReasons[status.State.Waiting.Reason] {
+			return true
+		}
+	}
+	return false
+}
+
+func isCrashLooping(pod *corev1.Pod) bool {
+	for _, status := range containerStatuses(pod) {
+		if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+			return true
+		}
+	}
+	return false
+}
+
+func countRestarts(pods []*corev1.Pod) int {
+	restarts := 0
+	for _, pod := range pods {
+		for _, status := range containerStatuses(pod) {
+			restarts += int(status.RestartCount)
+		}
+	}
+	return restarts
+}
+
+func countCrashLooping(pods []*corev1.Pod) int {
+	crashLooping := 0
+	for _, pod := range pods {
+		if isCrashLooping(pod) {
+			crashLooping++
+		}
+	}
+	return crashLooping
+}
+
+// filterPods drops pods outside the annotation filter, in excluded namespaces
+// (APP_EXCLUDE_NAMESPACES plus ?excludeNamespace=), matching APP_IGNORE_LABEL, or
+// owned by Jobs when completed pods are not included.
+func filterPods(pods []*corev1.Pod, params url.Values) []*corev1.Pod {
+	includeCompleted := conf.IncludeCompleted
+	if value := params.Get("includeCompleted"); value != "" {
+		includeCompleted = value == "true"
+	}
+	excluded := map[string]bool{}
+	for _, namespace := range append(splitList(params.Get("excludeNamespace")), conf.ExcludeNamespaces...) {
+		excluded[namespace] = true
+	}
+	ignore := labels.Nothing()
+	if conf.IgnoreLabel != "" {
+		if selector, err := labels.Parse(conf.IgnoreLabel); err == nil {
+			ignore = selector
+		}
+	}
+	annotation := params.Get("annotation")
+	var filtered []*corev1.Pod
+	for _, pod := range pods {
+		if excluded[pod.Namespace] || ignore.Matches(labels.Set(pod.Labels)) || !matchAnnotation(pod, annotation) {
+			continue
+		}
+		if !includeCompleted && isJobPod(pod) {
+			continue
+		}
+		filtered = append(filtered, pod)
+	}
+	return filtered
+}
+
+// evaluatePods lists the pods selected by params and splits them by health.
+func evaluatePods(ctx context.Context, params url.Values) (healthy, unhealthy []*corev1.Pod, err error) {
+	isHealthy, err := podHealthCheck(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	pods, err := listPods(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	var notReadyNodes map[string]bool
+	if params.Get("checkNode") == "true" {
+		notReadyNodes, err = listNotReadyNodes(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, pod := range filterPods(pods, params) {
+		if !notReadyNodes[pod.Spec.NodeName] && isHealthy(pod) {
+			healthy = append(healthy, pod)
+		} else {
+			unhealthy = append(unhealthy, pod)
+		}
+	}
+	return healthy, unhealthy, nil
+}
+
+func countPods(ctx context.Context, params url.Values) (healthCount, error) {
+	healthy, unhealthy, err := evaluatePods(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	return podCount(healthy, unhealthy), nil
+}
+
+func podCount(healthy, unhealthy []*corev1.Pod) healthCount {
+	count := healthCount{}
+	for _, pod := range healthy {
+		count.add(pod, true)
+	}
+	for _, pod := range unhealthy {
+		count.add(pod, false)
+	}
+	return count
+}
+
+// podFailureReasons collects the distinct waiting/termination reasons of the
+// given pods, falling back to the pod-level reason or phase.
+func podFailureReasons(pods []*corev1.Pod) map[string]bool {
+	reasons := map[string]bool{}
+	for _, pod := range pods {
+		found := false
+		for _, status := range containerStatuses(pod) {
+			if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+				reasons[status.State.Waiting.Reason] = true
+				found = true
+			}
+			if status.State.Terminated != nil && status.State.Terminated.Reason != "" && status.State.Terminated.Reason != "Completed" {
+				reasons[status.State.Terminated.Reason] = true
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+		if pod.Status.Reason != "" {
+			reasons[pod.Status.Reason] = true
+		} else {
+			reasons[string(pod.Status.Phase)] = true
+		}
+	}
+	return reasons
+}
+
+// pendingOnPVC returns the Pending pods among pods that mount a claim which is
+// missing or not yet Bound.
+func pendingOnPVC(ctx context.Context, params url.Values, pods []*corev1.Pod) ([]*corev1.Pod, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	pvcs, err := listPVCs(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate, Namespaces: q.Namespaces})
+	if err != nil {
+		return nil, err
+	}
+	bound := map[string]bool{}
+	for _, pvc := range pvcs {
+		bound[pvc.Namespace+"/"+pvc.Name] = pvc.Status.Phase == corev1.ClaimBound
+	}
+	var pending []*corev1.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && !bound[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] {
+				pending = append(pending, pod)
+				break
+			}
+		}
+	}
+	return pending, nil
+}
+
+func podsBadge(ctx context.Context, params url.Values) (badge, error) {
+	switch mode := params.Get("mode"); mode {
+	case "":
+	case "reasons":
+		return podReasonsBadge(ctx, params)
+	case "pvc-pending":
+		return podPVCPendingBadge(ctx, params)
+	default:
+		return badge{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown mode: %s", mode))
+	}
+	healthy, unhealthy, err := evaluatePods(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := countBadge(badgeLabel("pods", params), podCount(healthy, unhealthy), params)
+	if crashLooping := countCrashLooping(unhealthy); crashLooping > 0 {
+		b.Message += fmt.Sprintf(", %d crashlooping", crashLooping)
+	}
+	return b, nil
+}
+
+func podReasonsBadge(ctx context.Context, params url.Values) (badge, error) {
+	healthy, unhealthy, err := evaluatePods(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	reasons := len(podFailureReasons(unhealthy))
+	color := BADGE_COLOR_HEALTHY
+	if reasons > 1 {
+		color = BADGE_COLOR_FATAL
+	} else if reasons == 1 {
+		color = BADGE_COLOR_WARN
+	}
+	return badge{
+		Label:   badgeLabel("pods", params),
+		Message: fmt.Sprintf("%d reasons", reasons),
+		Color:   color,
+		Count:   podCount(healthy, unhealthy),
+	}, nil
+}
+
+func podPVCPendingBadge(ctx context.Context, params url.Values) (badge, error) {
+	healthy, unhealthy, err := evaluatePods(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	pending, err := pendingOnPVC(ctx, params, unhealthy)
+	if err != nil {
+		return badge{}, err
+	}
+	color := BADGE_COLOR_HEALTHY
+	if len(pending) > 0 {
+		color = BADGE_COLOR_FATAL
+	}
+	total := len(healthy) + len(unhealthy)
+	return badge{
+		Label:   badgeLabel("pods", params),
+		Message: fmt.Sprintf("%d pending on PVC", len(pending)),
+		Color:   color,
+		Count:   healthCount{Healthy: total - len(pending), Total: total},
+	}, nil
+}
+
+func handleAPIPods(ctx echo.Context) error {
+	if _, err := parseThresholds(ctx.QueryParams()); err != nil {
+		return respondError(ctx, err)
+	}
+	healthy, unhealthy, err := evaluatePods(ctx.Request().Context(), ctx.QueryParams())
+	if err != nil {
+		return respondError(ctx, err)
+	}
+	count := podCount(healthy, unhealthy)
+	rate := count.rate()
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"label":        badgeLabel("pods", ctx.QueryParams()),
+		"healthy":      count.Healthy,
+		"total":        count.Total,
+		"rate":         rate,
+		"percent":      math.Round(rate*1000) / 10,
+		"color":        count.color(ctx.QueryParams()),
+		"crashLooping": countCrashLooping(unhealthy),
+		"restarts":     countRestarts(healthy) + countRestarts(unhealthy),
+	})
+}
+
+// podBadge reports the phase, readiness and restarts of a single pod.
+func podBadge(namespace, name string) badgeFunc {
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		isHealthy, err := podHealthCheck(params)
+		if err != nil {
+			return badge{}, err
+		}
+		q, err := newListQuery(ctx, params)
+		if err != nil {
+			return badge{}, err
+		}
+		pod, err := getPod(ctx, q, namespace, name)
+		if apierrors.IsNotFound(err) {
+			return badge{}, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("pod %s/%s not found", namespace, name))
+		}
+		recordClusterCall(q.Cluster, err)
+		if err != nil {
+			kubernetesErrorsTotal.WithLabelValues("pods", q.Cluster).Inc()
+			return badge{}, err
+		}
+		count := healthCount{Total: 1}
+		color := BADGE_COLOR_FATAL
+		readiness := "not ready"
+		switch {
+		case isHealthy(pod):
+			count.Healthy = 1
+			color = BADGE_COLOR_HEALTHY
+			readiness = "ready"
+		case pod.Status.Phase == corev1.PodPending && !hasFailingContainer(pod):
+			color = BADGE_COLOR_WARN
+		}
+		if pod.Status.Phase == corev1.PodSucceeded {
+			readiness = "completed"
+		}
+		message := fmt.Sprintf("%s, %s", pod.Status.Phase, readiness)
+		if restarts := countRestarts([]*corev1.Pod{pod}); restarts > 0 {
+			message += fmt.Sprintf(", %d restarts", restarts)
+		}
+		return badge{Label: name, Message: message, Color: color, Count: count}, nil
+	}
+}
+
+func handlePod(ctx echo.Context) error {
+	namespace, name := ctx.Param("namespace"), ctx.Param("name")
+	return serveBadge(ctx, "pods/"+namespace+"/"+name, ctx.QueryParams(), podBadge(namespace, name))
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func podNames(pods []*corev1.Pod) []string {
+	names := []string{}
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestFilterPodsAnnotation(t *testing.T) {
+	setupTest(t, nil)
+	pods := []*corev1.Pod{
+		{ObjectMeta: v1.ObjectMeta{Name: "web", Annotations: map[string]string{"team": "web"}}},
+		{ObjectMeta: v1.ObjectMeta{Name: "api", Annotations: map[string]string{"team": "api"}}},
+		{ObjectMeta: v1.ObjectMeta{Name: "none"}},
+	}
+	tests := []struct {
+		annotation string
+		want       []string
+	}{
+		{annotation: "", want: []string{"web", "api", "none"}},
+		{annotation: "team=web", want: []string{"web"}},
+		{annotation: "team=db", want: []string{}},
+		{annotation: "team", want: []string{"web", "api"}},
+	}
+	for _, tt := range tests {
+		got := podNames(filterPods(pods, url.Values{"annotation": {tt.annotation}}))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("filterPods(annotation=%q) = %v, want %v", tt.annotation, got, tt.want)
+		}
+	}
+}
+
+func testPod(name string, ready bool, waiting string, restarts int32) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: restarts}},
+		},
+	}
+	if ready {
+		pod.Status.Conditions[0].Status = corev1.ConditionTrue
+	}
+	if waiting != "" {
+		pod.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: waiting}
+	}
+	return pod
+}
+
+func TestAPIPods(t *testing.T) {
+	setupTest(t, map[string]string{"APP_ENV": "prod"},
+		testPod("web", true, "", 2),
+		testPod("api", false, "CrashLoopBackOff", 5),
+	)
+	rec := get(t, "/api/pods")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"label":        "pods(prod)",
+		"healthy":      1.0,
+		"total":        2.0,
+		"rate":         0.5,
+		"percent":      50.0,
+		"color":        BADGE_COLOR_WARN,
+		"crashLooping": 1.0,
+		"restarts":     7.0,
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("/api/pods = %v, want %v", got, want)
+	}
+}
+
+func testNode(name string, ready bool) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: v1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}},
+	}
+}
+
+func TestCountPodsCheckNode(t *testing.T) {
+	onReady, onNotReady := testPod("a", true, "", 0), testPod("b", true, "", 0)
+	onReady.Spec.NodeName, onNotReady.Spec.NodeName = "n1", "n2"
+	setupTest(t, nil, testNode("n1", true), testNode("n2", false), onReady, onNotReady)
+	for _, tt := range []struct {
+		checkNode string
+		healthy   int
+	}{
+		{checkNode: "", healthy: 2},
+		{checkNode: "true", healthy: 1},
+	} {
+		count, err := countPods(context.Background(), url.Values{"checkNode": {tt.checkNode}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count.Healthy != tt.healthy || count.Total != 2 {
+			t.Errorf("checkNode=%q: count = %d/%d, want %d/2", tt.checkNode, count.Healthy, count.Total, tt.healthy)
+		}
+	}
+}
+
+func TestPodFailureReasons(t *testing.T) {
+	oomKilled := testPod("oom", false, "", 1)
+	oomKilled.Status.ContainerStatuses[0].State.Terminated = &corev1.ContainerStateTerminated{Reason: "OOMKilled"}
+	completed := testPod("init", false, "", 0)
+	completed.Status.InitContainerStatuses = []corev1.ContainerStatus{{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}}}}
+	evicted := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Name: "evicted"}, Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"}}
+	pending := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Name: "pending"}, Status: corev1.PodStatus{Phase: corev1.PodPending}}
+	pods := []*corev1.Pod{
+		testPod("a", false, "CrashLoopBackOff", 4),
+		testPod("b", false, "CrashLoopBackOff", 2),
+		oomKilled, completed, evicted, pending,
+	}
+
+	got := slices.Sorted(maps.Keys(podFailureReasons(pods)))
+	// completed has no failing container and falls back to its phase.
+	want := []string{"CrashLoopBackOff", "Evicted", "OOMKilled", "Pending", "Running"}
+	if !slices.Equal(got, want) {
+		t.Errorf("podFailureReasons = %v, want %v", got, want)
+	}
+}
+
+func TestPodReasonsBadge(t *testing.T) {
+	setupTest(t, nil, testPod("a", false, "CrashLoopBackOff", 4), testPod("b", false, "CrashLoopBackOff", 2), testPod("c", true, "", 0))
+	b, err := podsBadge(context.Background(), url.Values{"mode": {"reasons"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Message != "1 reasons" || b.Color != BADGE_COLOR_WARN {
+		t.Errorf("badge = %s %s, want 1 reasons %s", b.Message, b.Color, BADGE_COLOR_WARN)
+	}
+}
+
+func TestPodsBadgePVCPending(t *testing.T) {
+	mounting := func(name string, phase corev1.PodPhase, claim string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name:         "data",
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim}},
+			}}},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+	claim := func(name string, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+	setupTest(t, nil,
+		claim("bound", corev1.ClaimBound),
+		claim("unbound", corev1.ClaimPending),
+		mounting("waiting", corev1.PodPending, "unbound"),
+		mounting("missing", corev1.PodPending, "deleted"),
+		// Pending on its image, not its claim.
+		mounting("pulling", corev1.PodPending, "bound"),
+		testPod("web", true, "", 0),
+	)
+	b, err := podsBadge(context.Background(), url.Values{"mode": {"pvc-pending"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Message != "2 pending on PVC" || b.Color != BADGE_COLOR_FATAL || b.Count.Healthy != 2 || b.Count.Total != 4 {
+		t.Errorf("badge = %s %s %d/%d, want 2 pending on PVC %s 2/4", b.Message, b.Color, b.Count.Healthy, b.Count.Total, BADGE_COLOR_FATAL)
+	}
+}
+
+// BenchmarkCountPods evaluates a cached list of 1000 pods, so it measures the
+// filtering and health checks rather than the fake API server.
+func BenchmarkCountPods(b *testing.B) {
+	var pods []runtime.Object
+	for i := range 1000 {
+		pods = append(pods, testPod(fmt.Sprintf("pod-%d", i), i%10 != 0, "", int32(i%3)))
+	}
+	setupTest(b, map[string]string{"APP_CACHE_TTL": "1m"}, pods...)
+	ctx, params := context.Background(), url.Values{}
+	if _, err := countPods(ctx, params); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := countPods(ctx, params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
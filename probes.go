@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	PROBE_TYPE_HTTP = "http"
+	PROBE_TYPE_TCP  = "tcp"
+	PROBE_TYPE_ICMP = "icmp"
+)
+
+// probeDef is a blackbox check of a target outside the cluster, declared in
+// the probes section of the APP_CONFIG file and served at /probes/{name}.
+type probeDef struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Target is a URL for http, host:port for tcp and a host for icmp.
+	Target string `json:"target"`
+	// ExpectedStatus lists the accepted HTTP status codes; any 2xx or 3xx by default.
+	ExpectedStatus []int  `json:"expectedStatus"`
+	Timeout        string `json:"timeout"`
+	// Interval overrides APP_PROBE_INTERVAL for this probe, e.g. "1m".
+	Interval string `json:"interval"`
+	Label    string `json:"label"`
+}
+
+func (p probeDef) validate() error {
+	switch p.Type {
+	case PROBE_TYPE_HTTP:
+		if u, err := url.Parse(p.Target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid http target %q", p.Target)
+		}
+	case PROBE_TYPE_TCP:
+		if _, _, err := net.SplitHostPort(p.Target); err != nil {
+			return fmt.Errorf("invalid tcp target %q: want host:port", p.Target)
+		}
+	case PROBE_TYPE_ICMP:
+		if p.Target == "" {
+			return errors.New("icmp target is required")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", p.Type)
+	}
+	for _, value := range []string{p.Timeout, p.Interval} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	return nil
+}
+
+func (p probeDef) timeout() time.Duration {
+	if d, err := time.ParseDuration(p.Timeout); err == nil {
+		return d
+	}
+	return conf.ProbeTimeout
+}
+
+func (p probeDef) interval() time.Duration {
+	if d, err := time.ParseDuration(p.Interval); err == nil {
+		return d
+	}
+	return conf.ProbeInterval
+}
+
+// probeDefs is swapped together with badgeDefs on reload.
+var probeDefs atomic.Pointer[map[string]probeDef]
+
+type probeResult struct {
+	up      bool
+	reason  string
+	latency time.Duration
+}
+
+var (
+	probeResultsMu sync.Mutex
+	probeResults   = map[string]probeResult{}
+)
+
+func init() {
+	probeDefs.Store(&map[string]probeDef{})
+}
+
+func probeNames() []string {
+	defs := *probeDefs.Load()
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runProbe checks the target once, returning why it is down. HTTP probes share
+// the ingress probe client, so redirects count as the response they are.
+func runProbe(ctx context.Context, p probeDef) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+	switch p.Type {
+	case PROBE_TYPE_HTTP:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Target, nil)
+		if err != nil {
+			return err
+		}
+		res, err := probeClient.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if !expectedStatus(p.ExpectedStatus, res.StatusCode) {
+			return fmt.Errorf("status %d", res.StatusCode)
+		}
+		return nil
+	case PROBE_TYPE_TCP:
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", p.Target)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	default:
+		return ping(ctx, p.Target)
+	}
+}
+
+func expectedStatus(expected []int, status int) bool {
+	if len(expected) == 0 {
+		return status >= 200 && status < 400
+	}
+	for _, code := range expected {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ping sends one ICMP echo over an unprivileged datagram socket, which needs
+// the process group within net.ipv4.ping_group_range, falling back to a raw
+// socket for processes with CAP_NET_RAW.
+func ping(ctx context.Context, host string) error {
+	addr, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	var target net.IP
+	for _, a := range addr {
+		if a.IP.To4() != nil {
+			target = a.IP
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("no IPv4 address for %s", host)
+	}
+	var dst net.Addr = &net.UDPAddr{IP: target}
+	conn, err := icmp.ListenPacket("udp4", "0.0.0.0")
+	if err != nil {
+		dst = &net.IPAddr{IP: target}
+		if conn, err = icmp.ListenPacket("ip4:icmp", "0.0.0.0"); err != nil {
+			return err
+		}
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	seq := int(time.Now().UnixNano() & 0xffff)
+	message := icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: os.Getpid() & 0xffff, Seq: seq, Data: []byte("k8s-status-badge")}}
+	data, err := message.Marshal(nil)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.WriteTo(data, dst); err != nil {
+		return err
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		// The kernel rewrites the echo ID of datagram sockets, so match on Seq.
+		reply, err := icmp.ParseMessage(ipv4.ICMPTypeEchoReply.Protocol(), buf[:n])
+		if err != nil {
+			continue
+		}
+		if body, ok := reply.Body.(*icmp.Echo); ok && reply.Type == ipv4.ICMPTypeEchoReply && body.Seq == seq {
+			return nil
+		}
+	}
+}
+
+// probeReason shortens err for the badge message; the full error is logged.
+func probeReason(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout(), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case strings.HasPrefix(err.Error(), "status "):
+		return err.Error()
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return "connection refused"
+	}
+	return "unreachable"
+}
+
+func checkProbe(ctx context.Context, p probeDef) {
+	start := time.Now()
+	err := runProbe(ctx, p)
+	result := probeResult{up: err == nil, latency: time.Since(start)}
+	if err != nil {
+		result.reason = probeReason(err)
+		slog.Debug("probe failed", "probe", p.Name, "target", p.Target, "error", err.Error())
+	}
+	probeResultsMu.Lock()
+	probeResults[p.Name] = result
+	probeResultsMu.Unlock()
+}
+
+// watchProbes runs every probe once its interval has passed, each in its own
+// goroutine so a slow target cannot delay the others.
+func watchProbes(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	var running sync.Map
+	lastRun := map[string]time.Time{}
+	for {
+		now := time.Now()
+		for _, p := range *probeDefs.Load() {
+			if now.Sub(lastRun[p.Name]) < p.interval() {
+				continue
+			}
+			if _, busy := running.LoadOrStore(p.Name, true); busy {
+				continue
+			}
+			lastRun[p.Name] = now
+			go func() {
+				defer running.Delete(p.Name)
+				checkProbe(ctx, p)
+			}()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeBadge reports the latest result of the probe name, e.g. "up 45ms" or
+// "down: timeout".
+func probeBadge(name string) badgeFunc {
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		p, ok := (*probeDefs.Load())[name]
+		if !ok {
+			return badge{}, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unknown probe: %s", name))
+		}
+		label := p.Label
+		if label == "" {
+			label = name
+		}
+		if params.Get("label") != "" {
+			label = params.Get("label")
+		}
+		probeResultsMu.Lock()
+		result, checked := probeResults[name]
+		probeResultsMu.Unlock()
+		if !checked {
+			return badge{Label: label, Message: "pending", Color: BADGE_COLOR_ERROR}, nil
+		}
+		if !result.up {
+			return badge{Label: label, Message: "down: " + result.reason, Color: BADGE_COLOR_FATAL, Count: healthCount{Total: 1}}, nil
+		}
+		return badge{
+			Label:   label,
+			Message: fmt.Sprintf("up %dms", result.latency.Milliseconds()),
+			Color:   BADGE_COLOR_HEALTHY,
+			Count:   healthCount{Healthy: 1, Total: 1},
+		}, nil
+	}
+}
+
+func handleProbe(ctx echo.Context) error {
+	name := ctx.Param("name")
+	return serveBadge(ctx, "probes/"+name, ctx.QueryParams(), probeBadge(name))
+}
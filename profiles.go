@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// healthProfile is a named pod health definition declared in the profiles
+// section of the APP_CONFIG file and selected with ?profile=, e.g. a strict
+// profile requiring Ready and no restarts in 15m next to a lenient one only
+// looking at the phase.
+type healthProfile struct {
+	Name string `json:"name"`
+	// Phases are the healthy phases; Running and Succeeded by default.
+	Phases []corev1.PodPhase `json:"phases"`
+	// RequireReady fails running pods without the Ready condition.
+	RequireReady bool `json:"requireReady"`
+	// AllowFailingContainers keeps pods healthy while a container is in
+	// CrashLoopBackOff or an image pull back-off.
+	AllowFailingContainers bool `json:"allowFailingContainers"`
+	// RestartWindow fails pods with a container restarted within it, e.g. "15m".
+	RestartWindow string `json:"restartWindow"`
+
+	restartWindow time.Duration
+}
+
+var podPhases = []corev1.PodPhase{corev1.PodPending, corev1.PodRunning, corev1.PodSucceeded, corev1.PodFailed, corev1.PodUnknown}
+
+func (p *healthProfile) parse() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, phase := range p.Phases {
+		if !slices.Contains(podPhases, phase) {
+			return fmt.Errorf("unknown phase %q", phase)
+		}
+	}
+	if len(p.Phases) == 0 {
+		p.Phases = []corev1.PodPhase{corev1.PodRunning, corev1.PodSucceeded}
+	}
+	if p.RestartWindow != "" {
+		window, err := time.ParseDuration(p.RestartWindow)
+		if err != nil || window <= 0 {
+			return fmt.Errorf("invalid restartWindow %q", p.RestartWindow)
+		}
+		p.restartWindow = window
+	}
+	return nil
+}
+
+func (p healthProfile) healthy(pod *corev1.Pod) bool {
+	if !slices.Contains(p.Phases, pod.Status.Phase) {
+		return false
+	}
+	if !p.AllowFailingContainers && hasFailingContainer(pod) {
+		return false
+	}
+	if p.restartWindow > 0 && restartedSince(pod, time.Now().Add(-p.restartWindow)) {
+		return false
+	}
+	if p.RequireReady && pod.Status.Phase == corev1.PodRunning {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady {
+				return condition.Status == corev1.ConditionTrue
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// restartedSince reports whether a container of pod restarted after since,
+// judged by when its previous instance terminated.
+func restartedSince(pod *corev1.Pod, since time.Time) bool {
+	for _, status := range containerStatuses(pod) {
+		if terminated := status.LastTerminationState.Terminated; status.RestartCount > 0 && terminated != nil && terminated.FinishedAt.After(since) {
+			return true
+		}
+	}
+	return false
+}
+
+// healthProfiles is swapped together with badgeDefs on reload.
+var healthProfiles atomic.Pointer[map[string]healthProfile]
+
+func init() {
+	healthProfiles.Store(&map[string]healthProfile{})
+}
+
+// podHealthCheck returns the health definition selected by ?profile=, or
+// isPodHealthy without one.
+func podHealthCheck(params url.Values) (func(*corev1.Pod) bool, error) {
+	name := params.Get("profile")
+	if name == "" {
+		return isPodHealthy, nil
+	}
+	profile, ok := (*healthProfiles.Load())[name]
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown profile: %s", name))
+	}
+	return profile.healthy, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "pvcs",
+			enabled: func() bool { return conf.EnablePVCs },
+			badge:   pvcsBadge,
+		},
+		count: countPVCs,
+	})
+}
+
+// evaluatePVCs counts Bound claims as healthy and tallies the Lost and Pending ones.
+func evaluatePVCs(ctx context.Context, params url.Values) (count healthCount, lost, pending int, err error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, 0, 0, err
+	}
+	pvcs, err := listPVCs(ctx, q)
+	if err != nil {
+		return healthCount{}, 0, 0, err
+	}
+	annotation := params.Get("annotation")
+	for _, pvc := range pvcs {
+		if !matchAnnotation(pvc, annotation) {
+			continue
+		}
+		count.add(pvc, pvc.Status.Phase == corev1.ClaimBound)
+		switch pvc.Status.Phase {
+		case corev1.ClaimLost:
+			lost++
+		case corev1.ClaimPending:
+			pending++
+		}
+	}
+	return count, lost, pending, nil
+}
+
+func countPVCs(ctx context.Context, params url.Values) (healthCount, error) {
+	count, _, _, err := evaluatePVCs(ctx, params)
+	return count, err
+}
+
+func pvcsBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, lost, pending, err := evaluatePVCs(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := countBadge(badgeLabel("pvcs", params), count, params)
+	if lost > 0 {
+		b.Message += fmt.Sprintf(", %d lost", lost)
+		b.Color = BADGE_COLOR_FATAL
+	}
+	if pending > 0 {
+		b.Message += fmt.Sprintf(", %d pending", pending)
+		if b.Color == BADGE_COLOR_HEALTHY {
+			b.Color = BADGE_COLOR_WARN
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "quotas",
+			enabled: func() bool { return conf.EnableQuotas },
+			badge:   quotasBadge,
+			routes: map[string]echo.HandlerFunc{
+				"/quotas/:namespace": handleNamespaceQuotas,
+			},
+		},
+		count: countQuotas,
+	})
+}
+
+// quotaUtilization returns the highest used/hard ratio across the resources of
+// quota and the resource it belongs to.
+func quotaUtilization(quota *corev1.ResourceQuota) (float64, corev1.ResourceName) {
+	highest := 0.0
+	var highestName corev1.ResourceName
+	for name, hard := range quota.Status.Hard {
+		used, ok := quota.Status.Used[name]
+		if !ok {
+			continue
+		}
+		utilization := 0.0
+		switch {
+		case hard.Sign() > 0:
+			utilization = float64(used.MilliValue()) / float64(hard.MilliValue())
+		case used.Sign() > 0:
+			// A zero hard limit that is already used is exhausted.
+			utilization = 1
+		}
+		if highestName == "" || utilization > highest {
+			highest, highestName = utilization, name
+		}
+	}
+	return highest, highestName
+}
+
+// evaluateQuotas counts quotas below APP_USAGE_WARN_THRESHOLD as healthy and
+// returns the highest utilization with the quota and resource behind it.
+func evaluateQuotas(ctx context.Context, params url.Values) (count healthCount, highest float64, where string, err error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, 0, "", err
+	}
+	quotas, err := listResourceQuotas(ctx, q)
+	if err != nil {
+		return healthCount{}, 0, "", err
+	}
+	annotation := params.Get("annotation")
+	for _, quota := range quotas {
+		if !matchAnnotation(quota, annotation) {
+			continue
+		}
+		utilization, name := quotaUtilization(quota)
+		count.add(quota, utilization < conf.UsageWarnThreshold)
+		if where == "" || utilization > highest {
+			highest = utilization
+			where = fmt.Sprintf("%s in %s/%s", name, quota.Namespace, quota.Name)
+		}
+	}
+	return count, highest, where, nil
+}
+
+func countQuotas(ctx context.Context, params url.Values) (healthCount, error) {
+	count, _, _, err := evaluateQuotas(ctx, params)
+	return count, err
+}
+
+// quotasBadge reports the highest quota utilization, e.g. "87% requests.cpu in team-a/compute".
+func quotasBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, highest, where, err := evaluateQuotas(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	b := badge{
+		Label:   badgeLabel("quotas", params),
+		Message: "no quotas",
+		Color:   BADGE_COLOR_HEALTHY,
+		Count:   count,
+	}
+	if count.Total > 0 {
+		b.Message = fmt.Sprintf("%.0f%% %s", highest*100, where)
+		b.Color = usageColor(highest)
+	}
+	return b, nil
+}
+
+// handleNamespaceQuotas serves the quotas badge of a single namespace.
+func handleNamespaceQuotas(ctx echo.Context) error {
+	namespace := ctx.Param("namespace")
+	params := url.Values{}
+	for key, values := range ctx.QueryParams() {
+		params[key] = values
+	}
+	params.Set("namespace", namespace)
+	return serveBadge(ctx, "quotas/"+namespace, params, quotasBadge)
+}
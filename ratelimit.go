@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitMiddleware allows each client IP limit requests per second with
+// bursts of up to burst requests. Probes share the auth exemptions.
+func rateLimitMiddleware(limit float64, burst int) echo.MiddlewareFunc {
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Skipper: func(ctx echo.Context) bool {
+			return authExemptPaths[ctx.Path()]
+		},
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(limit),
+			Burst:     burst,
+			ExpiresIn: 3 * time.Minute,
+		}),
+		IdentifierExtractor: func(ctx echo.Context) (string, error) {
+			return ctx.RealIP(), nil
+		},
+		ErrorHandler: func(ctx echo.Context, err error) error {
+			return respondError(ctx, echo.NewHTTPError(http.StatusForbidden, "unidentified client"))
+		},
+		DenyHandler: func(ctx echo.Context, identifier string, err error) error {
+			return respondError(ctx, echo.NewHTTPError(http.StatusTooManyRequests, "rate limited"))
+		},
+	})
+}
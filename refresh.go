@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// presentationParams only change how a badge is rendered, so a precomputed
+// badge can serve requests that differ in them.
+var presentationParams = map[string]bool{
+	"label":        true,
+	"healthyColor": true,
+	"warnColor":    true,
+	"fatalColor":   true,
+	"style":        true,
+	"logo":         true,
+	"logoColor":    true,
+	"labelColor":   true,
+	"cacheSeconds": true,
+	"format":       true,
+	"link":         true,
+}
+
+func refreshKey(name string, params url.Values) string {
+	keyParams := url.Values{}
+	for key, values := range params {
+		if !presentationParams[key] && !credentialParams[key] {
+			keyParams[key] = values
+		}
+	}
+	return name + "?" + keyParams.Encode()
+}
+
+type refreshResult struct {
+	badge badge
+	err   error
+}
+
+var (
+	precomputedMu sync.Mutex
+	// precomputed holds the latest background evaluation of each listed badge.
+	precomputed = map[string]refreshResult{}
+)
+
+// precomputedBadge returns the latest background evaluation matching name and
+// params. Requests with ?nocache=true always compute the badge themselves.
+func precomputedBadge(name string, params url.Values) (badge, error, bool) {
+	if params.Get("nocache") == "true" {
+		return badge{}, nil, false
+	}
+	precomputedMu.Lock()
+	defer precomputedMu.Unlock()
+	result, ok := precomputed[refreshKey(name, params)]
+	return result.badge, result.err, ok
+}
+
+// refreshInterval is the refresh interval of a named badge from the APP_CONFIG
+// file, or APP_REFRESH_INTERVAL.
+func refreshInterval(name string) time.Duration {
+	defName, named := strings.CutPrefix(name, "badge/")
+	if def, ok := (*badgeDefs.Load())[defName]; named && ok && def.RefreshInterval != "" {
+		if interval, err := time.ParseDuration(def.RefreshInterval); err == nil {
+			return interval
+		}
+	}
+	return conf.RefreshInterval
+}
+
+// watchRefresh recomputes every listed badge once its refresh interval has
+// passed, on a pool of workers so slow badges cannot delay the others beyond
+// the pool size.
+func watchRefresh(ctx context.Context, workers int) {
+	jobs := make(chan listedBadge)
+	var running sync.Map
+	for range max(workers, 1) {
+		go func() {
+			for l := range jobs {
+				refreshBadge(ctx, l)
+				running.Delete(l.name)
+			}
+		}()
+	}
+	defer close(jobs)
+
+	next := map[string]time.Time{}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		for _, l := range listedBadges() {
+			if now.Before(next[l.name]) {
+				continue
+			}
+			if _, busy := running.LoadOrStore(l.name, true); busy {
+				continue
+			}
+			next[l.name] = now.Add(refreshInterval(l.name))
+			select {
+			case jobs <- l:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func refreshBadge(ctx context.Context, l listedBadge) {
+	params := url.Values{}
+	for key, values := range l.params {
+		params[key] = values
+	}
+	params.Set("nocache", "true")
+	// Stored unpresented: serveBadge presents it for each request.
+	b, err := evaluateBadge(ctx, l.name, params, l.compute)
+	precomputedMu.Lock()
+	defer precomputedMu.Unlock()
+	precomputed[refreshKey(l.name, l.params)] = refreshResult{badge: b, err: err}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestPrecomputedBadgePresentedOnce(t *testing.T) {
+	setupTest(t, nil, testPod("web", false, "", 0))
+	clear(precomputed)
+	params := url.Values{"fatalColor": {"purple"}}
+	refreshBadge(context.Background(), listedBadge{name: "pods", params: params, compute: podsBadge})
+
+	b, err, ok := precomputedBadge("pods", params)
+	if !ok || err != nil {
+		t.Fatalf("precomputedBadge = %v, %v", ok, err)
+	}
+	b = applyPresentation(b, params)
+	if b.Color != "purple" || b.Level != BADGE_LEVEL_FATAL {
+		t.Errorf("presented badge = %s %q, want purple %s", b.Color, b.Level, BADGE_LEVEL_FATAL)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// renderKey identifies a rendering of the fields the shields and SVG formats
+// show, so identical badges share one body whatever their counts.
+type renderKey struct {
+	format, label, message, color, style, logo string
+	labelColor, logoColor, link                string
+	cacheSeconds                               int
+	// logoSVG marks the APP_BADGE_LOGO_SVG logo, which is the same for all.
+	logoSVG bool
+}
+
+// rendered is an encoded badge body with its ETag.
+type rendered struct {
+	body []byte
+	etag string
+}
+
+var (
+	renderedMu sync.Mutex
+	// renderedBodies holds the bodies of recently rendered badges. Cached
+	// badges keep rendering the same few bodies, so encoding each once saves
+	// the allocations of marshaling on every request of a busy README.
+	renderedBodies = map[renderKey]rendered{}
+)
+
+func newRendered(body []byte) rendered {
+	hash := fnv.New64a()
+	hash.Write(body)
+	return rendered{body: body, etag: fmt.Sprintf(`"%x"`, hash.Sum64())}
+}
+
+// renderCached returns the body of b in format, encoding it with render on a
+// miss. The cache is emptied once it holds APP_RENDER_CACHE_SIZE bodies.
+func renderCached(format string, b badge, render func() ([]byte, error)) (rendered, error) {
+	key := renderKey{
+		format: format, label: b.Label, message: b.Message, color: b.Color, style: b.Style, logo: b.NamedLogo,
+		labelColor: b.LabelColor, logoColor: b.LogoColor, link: b.Link, cacheSeconds: b.CacheSeconds, logoSVG: b.LogoSVG != "",
+	}
+	renderedMu.Lock()
+	r, ok := renderedBodies[key]
+	renderedMu.Unlock()
+	if ok {
+		return r, nil
+	}
+	body, err := render()
+	if err != nil {
+		return rendered{}, err
+	}
+	r = newRendered(body)
+	if conf.RenderCacheSize <= 0 {
+		return r, nil
+	}
+	renderedMu.Lock()
+	defer renderedMu.Unlock()
+	if len(renderedBodies) >= conf.RenderCacheSize {
+		clear(renderedBodies)
+	}
+	renderedBodies[key] = r
+	return r, nil
+}
+
+func renderShields(b badge) ([]byte, error) {
+	return json.Marshal(badgeJSON(b))
+}
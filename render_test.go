@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+var benchmarkBadge = badge{Label: "pods(prod)", Message: "41/42, 1 crashlooping", Color: BADGE_COLOR_WARN, NamedLogo: "kubernetes"}
+
+func BenchmarkRenderSVG(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		renderSVG(benchmarkBadge)
+	}
+}
+
+func BenchmarkRenderShields(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := renderShields(benchmarkBadge); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRenderCached measures a hit, the common case of a badge whose
+// message did not change.
+func BenchmarkRenderCached(b *testing.B) {
+	setupTest(b, nil)
+	render := func() ([]byte, error) { return renderShields(benchmarkBadge) }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := renderCached("shields", benchmarkBadge, render); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+func badgeHandler(name string, compute badgeFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		params := ctx.QueryParams()
+		if cluster := ctx.Param("cluster"); cluster != "" {
+			params = url.Values{}
+			for key, values := range ctx.QueryParams() {
+				params[key] = values
+			}
+			params.Set("cluster", cluster)
+		}
+		return serveBadge(ctx, name, params, compute)
+	}
+}
+
+// listedBadge is a badge that can be evaluated without request parameters; its
+// name doubles as its path.
+type listedBadge struct {
+	name    string
+	params  url.Values
+	compute badgeFunc
+}
+
+// listedBadges returns every enabled resource badge followed by the named badges.
+func listedBadges() []listedBadge {
+	var result []listedBadge
+	for _, e := range enabledEvaluators() {
+		// custom badges cannot be evaluated without their query parameters.
+		if e.Name() == "custom" {
+			continue
+		}
+		result = append(result, listedBadge{e.Name(), url.Values{}, e.Evaluate})
+	}
+	defs := *badgeDefs.Load()
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := defs[name]
+		if compute, ok := def.evaluate(); ok {
+			result = append(result, listedBadge{"badge/" + name, def.params(), compute})
+		}
+	}
+	for _, name := range probeNames() {
+		result = append(result, listedBadge{"probes/" + name, url.Values{}, probeBadge(name)})
+	}
+	return result
+}
+
+// lookupBadge resolves an enabled resource name, "badge/<name>" of a named
+// badge or "probes/<name>" of a probe to its parameters and badge function.
+func lookupBadge(name string) (url.Values, badgeFunc, error) {
+	if defName, ok := strings.CutPrefix(name, "badge/"); ok {
+		def, ok := (*badgeDefs.Load())[defName]
+		if !ok {
+			return nil, nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unknown badge: %s", defName))
+		}
+		compute, ok := def.evaluate()
+		if !ok {
+			return nil, nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unknown badge: %s", defName))
+		}
+		return def.params(), compute, nil
+	}
+	if probeName, ok := strings.CutPrefix(name, "probes/"); ok {
+		if _, ok := (*probeDefs.Load())[probeName]; !ok {
+			return nil, nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unknown probe: %s", probeName))
+		}
+		return url.Values{}, probeBadge(probeName), nil
+	}
+	e, ok := findEvaluator(name)
+	if !ok {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unknown resource: %s", name))
+	}
+	return url.Values{}, e.Evaluate, nil
+}
+
+// serveBadge computes the badge cached under name and writes it, or an error badge.
+// With APP_REFRESH_INTERVAL set, listed badges are served from their latest
+// background evaluation instead.
+func serveBadge(ctx echo.Context, name string, params url.Values, compute badgeFunc) error {
+	b, err, ok := precomputedBadge(name, params)
+	if ok && err == nil {
+		b = applyPresentation(b, params)
+	} else if !ok {
+		b, err = computeBadge(ctx.Request().Context(), name, params, compute)
+	}
+	if err != nil {
+		return respondError(ctx, err)
+	}
+	return renderBadge(ctx, b)
+}
+
+// computeBadge computes the badge through badgeCache, keyed by name and the
+// parameters other than nocache, and presents it for params.
+func computeBadge(ctx context.Context, name string, params url.Values, compute badgeFunc) (badge, error) {
+	b, err := evaluateBadge(ctx, name, params, compute)
+	if err != nil {
+		return badge{}, err
+	}
+	return applyPresentation(b, params), nil
+}
+
+// evaluateBadge is computeBadge without the presentation, for badges that are
+// stored and presented once per request.
+func evaluateBadge(ctx context.Context, name string, params url.Values, compute badgeFunc) (badge, error) {
+	if _, err := parseThresholds(params); err != nil {
+		return badge{}, err
+	}
+	if _, err := parseEmptyState(params); err != nil {
+		return badge{}, err
+	}
+	if _, err := parseCacheSeconds(params); err != nil {
+		return badge{}, err
+	}
+	keyParams := url.Values{}
+	for key, values := range params {
+		if key != "nocache" && !credentialParams[key] {
+			keyParams[key] = values
+		}
+	}
+	key := name + "?" + keyParams.Encode()
+	b, err := cached(ctx, badgeCache, key, params.Get("nocache") == "true", func() (badge, error) {
+		ctx := ctx
+		if conf.BadgeTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, conf.BadgeTimeout)
+			defer cancel()
+		}
+		ctx, reads := withInformerReads(ctx)
+		b, err := compute(ctx, params)
+		if err == nil {
+			markEvaluated(name)
+			recordDependencies(key, name, reads.list())
+		}
+		return b, err
+	})
+	if err != nil {
+		stale, ok := staleBadge(key, err)
+		if !ok {
+			return badge{}, err
+		}
+		slog.Warn("serving stale badge", "badge", name, "error", err.Error())
+		return stale, nil
+	}
+	b.Name = name
+	rememberBadge(key, b)
+	recordBadge(name, b)
+	return b, nil
+}
+
+// anyResourcesFound reports whether at least one enabled resource lists a non-empty result.
+func anyResourcesFound(ctx context.Context) (bool, error) {
+	for _, e := range enabledEvaluators() {
+		counter, ok := e.(Counter)
+		if !ok {
+			continue
+		}
+		count, err := counter.Count(ctx, url.Values{})
+		if err != nil {
+			return false, err
+		}
+		if count.Total > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
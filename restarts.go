@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "restarts",
+			enabled: func() bool { return conf.EnableRestarts },
+			badge:   restartsBadge,
+		},
+		count: countRestartedPods,
+	})
+}
+
+// RESTART_SAMPLES_RETENTION bounds how long restart counts are remembered.
+const RESTART_SAMPLES_RETENTION = 24 * time.Hour
+
+type restartSample struct {
+	at       time.Time
+	restarts int32
+}
+
+var (
+	restartSamplesMu sync.Mutex
+	// restartSamples holds the cumulative restart count of each pod as observed
+	// over time, oldest first, since the API only exposes the current total.
+	restartSamples = map[types.UID][]restartSample{}
+)
+
+func podRestarts(pod *corev1.Pod) int32 {
+	var restarts int32
+	for _, status := range containerStatuses(pod) {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
+
+// lastRestart returns when a container of pod last terminated before restarting.
+func lastRestart(pod *corev1.Pod) time.Time {
+	var last time.Time
+	for _, status := range containerStatuses(pod) {
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.FinishedAt.After(last) {
+			last = terminated.FinishedAt.Time
+		}
+	}
+	return last
+}
+
+// observeRestarts records the restart counts of pods at now and reports for
+// each pod whether it restarted after since: either its count grew since the
+// oldest sample after since, or its last restart happened after since.
+func observeRestarts(pods []*corev1.Pod, now, since time.Time) map[types.UID]bool {
+	restartSamplesMu.Lock()
+	defer restartSamplesMu.Unlock()
+	restarted := map[types.UID]bool{}
+	for _, pod := range pods {
+		restarts := podRestarts(pod)
+		samples := restartSamples[pod.UID]
+		for len(samples) > 0 && now.Sub(samples[0].at) > RESTART_SAMPLES_RETENTION {
+			samples = samples[1:]
+		}
+		for _, sample := range samples {
+			if !sample.at.Before(since) {
+				restarted[pod.UID] = restarts > sample.restarts
+				break
+			}
+		}
+		if lastRestart(pod).After(since) {
+			restarted[pod.UID] = true
+		}
+		restartSamples[pod.UID] = append(samples, restartSample{at: now, restarts: restarts})
+	}
+	for uid, samples := range restartSamples {
+		if now.Sub(samples[len(samples)-1].at) > RESTART_SAMPLES_RETENTION {
+			delete(restartSamples, uid)
+		}
+	}
+	return restarted
+}
+
+// evaluateRestarts counts pods that did not restart within ?window=
+// (APP_RESTARTS_WINDOW) as healthy.
+func evaluateRestarts(ctx context.Context, params url.Values) (healthCount, string, error) {
+	window := conf.RestartsWindow
+	if value := params.Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return healthCount{}, "", echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid window: %s", value))
+		}
+		window = parsed
+	}
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, "", err
+	}
+	pods, err := listPods(ctx, q)
+	if err != nil {
+		return healthCount{}, "", err
+	}
+	pods = filterPods(pods, params)
+	now := time.Now()
+	restarted := observeRestarts(pods, now, now.Add(-window))
+	count := healthCount{}
+	for _, pod := range pods {
+		count.add(pod, !restarted[pod.UID])
+	}
+	return count, shortDuration(window), nil
+}
+
+func countRestartedPods(ctx context.Context, params url.Values) (healthCount, error) {
+	count, _, err := evaluateRestarts(ctx, params)
+	return count, err
+}
+
+// shortDuration formats d without trailing zero units, e.g. "1h" rather than "1h0m0s".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+func restartsBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, window, err := evaluateRestarts(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	restarted := count.Total - count.Healthy
+	color := BADGE_COLOR_HEALTHY
+	if restarted >= conf.RestartsFatal {
+		color = BADGE_COLOR_FATAL
+	} else if restarted >= conf.RestartsWarn {
+		color = BADGE_COLOR_WARN
+	}
+	return badge{
+		Label:   badgeLabel("restarts", params),
+		Message: fmt.Sprintf("%d restarted in %s", restarted, window),
+		Color:   color,
+		Count:   count,
+	}, nil
+}
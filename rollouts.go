@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "rollouts",
+			enabled: func() bool { return conf.EnableRollouts },
+			badge:   rolloutsBadge,
+		},
+		count: countRollouts,
+	})
+}
+
+const DEPLOYMENT_REVISION_ANNOTATION = "deployment.kubernetes.io/revision"
+
+// isRolloutStalled reports whether the rollout of deployment left its new
+// ReplicaSet short of the desired replicas while an old one still runs pods,
+// for longer than progressDeadlineSeconds or until the controller gave up.
+func isRolloutStalled(deployment *appsv1.Deployment, replicaSets []*appsv1.ReplicaSet, now time.Time) bool {
+	revision := deployment.Annotations[DEPLOYMENT_REVISION_ANNOTATION]
+	var current *appsv1.ReplicaSet
+	oldRunning := false
+	for _, rs := range replicaSets {
+		owner := v1.GetControllerOf(rs)
+		if owner == nil || owner.UID != deployment.UID {
+			continue
+		}
+		if rs.Annotations[DEPLOYMENT_REVISION_ANNOTATION] == revision {
+			current = rs
+		} else if rs.Status.Replicas > 0 {
+			oldRunning = true
+		}
+	}
+	if current == nil || !oldRunning {
+		return false
+	}
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	if current.Status.AvailableReplicas >= desired {
+		return false
+	}
+	deadline := 600 * time.Second
+	if deployment.Spec.ProgressDeadlineSeconds != nil {
+		deadline = time.Duration(*deployment.Spec.ProgressDeadlineSeconds) * time.Second
+	}
+	return isRolloutStuck(deployment) || now.Sub(current.CreationTimestamp.Time) > deadline
+}
+
+// countRollouts counts deployments whose rollout has not stalled as healthy.
+func countRollouts(ctx context.Context, params url.Values) (healthCount, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	deployments, err := listDeployments(ctx, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	// ReplicaSets carry the pod template labels, so the selector does not apply to them.
+	replicaSets, err := listReplicaSets(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate, Namespaces: q.Namespaces})
+	if err != nil {
+		return healthCount{}, err
+	}
+	annotation := params.Get("annotation")
+	now := time.Now()
+	count := healthCount{}
+	for _, deployment := range deployments {
+		if !matchAnnotation(deployment, annotation) {
+			continue
+		}
+		count.add(deployment, !isRolloutStalled(deployment, replicaSets, now))
+	}
+	return count, nil
+}
+
+func rolloutsBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countRollouts(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	stuck := count.Total - count.Healthy
+	b := badge{
+		Label:   badgeLabel("rollouts", params),
+		Message: fmt.Sprintf("%d/%d stuck", stuck, count.Total),
+		Color:   BADGE_COLOR_HEALTHY,
+		Count:   count,
+	}
+	if stuck > 0 {
+		b.Color = BADGE_COLOR_FATAL
+	}
+	return b, nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+)
+
+// runSelfTest lists each resource once at startup so operators can see what the
+// service observes before the first badge request arrives.
+func runSelfTest(ctx context.Context) {
+	for _, e := range enabledEvaluators() {
+		counter, ok := e.(Counter)
+		if !ok {
+			continue
+		}
+		count, err := counter.Count(ctx, url.Values{})
+		if err != nil {
+			slog.Error("self-test failed", "resource", e.Name(), "error", err.Error())
+			continue
+		}
+		slog.Info("self-test", "resource", e.Name(), "healthy", count.Healthy, "total", count.Total)
+	}
+}
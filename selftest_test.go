@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRunSelfTest(t *testing.T) {
+	setupTest(t, nil, testPod("web", true, "", 0), testPod("api", false, "", 0), testNode("n1", true))
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	runSelfTest(context.Background())
+
+	counts := map[string][2]int{}
+	decoder := json.NewDecoder(&logs)
+	for decoder.More() {
+		var record struct {
+			Msg      string `json:"msg"`
+			Resource string `json:"resource"`
+			Healthy  int    `json:"healthy"`
+			Total    int    `json:"total"`
+		}
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Msg == "self-test" {
+			counts[record.Resource] = [2]int{record.Healthy, record.Total}
+		}
+	}
+	if counts["pods"] != [2]int{1, 2} {
+		t.Errorf("pods self-test = %v, want [1 2]", counts["pods"])
+	}
+	if counts["nodes"] != [2]int{1, 1} {
+		t.Errorf("nodes self-test = %v, want [1 1]", counts["nodes"])
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "services",
+			enabled: func() bool { return conf.EnableServices },
+			badge:   servicesBadge,
+			routes: map[string]echo.HandlerFunc{
+				"/services/:namespace/:name": handleService,
+			},
+		},
+		count: countServices,
+	})
+}
+
+// endpointCount counts the distinct endpoints of slices. Dual-stack services
+// get one slice per address family, so an endpoint is identified by its target
+// pod and only falls back to its first address without one.
+func endpointCount(slices []*discoveryv1.EndpointSlice) (ready, total int) {
+	seen := map[string]bool{}
+	isReady := map[string]bool{}
+	for _, slice := range slices {
+		for _, endpoint := range slice.Endpoints {
+			key := ""
+			switch {
+			case endpoint.TargetRef != nil:
+				key = endpoint.TargetRef.Namespace + "/" + endpoint.TargetRef.Name
+			case len(endpoint.Addresses) > 0:
+				key = endpoint.Addresses[0]
+			default:
+				continue
+			}
+			seen[key] = true
+			// A nil ready condition means unknown and is treated as ready.
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				isReady[key] = true
+			}
+		}
+	}
+	return len(isReady), len(seen)
+}
+
+// countServices counts services with at least one ready endpoint as healthy.
+// ExternalName services have no endpoints and are skipped.
+func countServices(ctx context.Context, params url.Values) (healthCount, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	services, err := listServices(ctx, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	slices, err := listEndpointSlices(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Namespaces: q.Namespaces, Impersonate: q.Impersonate})
+	if err != nil {
+		return healthCount{}, err
+	}
+	byService := map[string][]*discoveryv1.EndpointSlice{}
+	for _, slice := range slices {
+		if service, ok := slice.Labels[discoveryv1.LabelServiceName]; ok {
+			byService[slice.Namespace+"/"+service] = append(byService[slice.Namespace+"/"+service], slice)
+		}
+	}
+	annotation := params.Get("annotation")
+	count := healthCount{}
+	for _, service := range services {
+		if service.Spec.Type == corev1.ServiceTypeExternalName || !matchAnnotation(service, annotation) {
+			continue
+		}
+		ready, _ := endpointCount(byService[service.Namespace+"/"+service.Name])
+		count.add(service, ready > 0)
+	}
+	return count, nil
+}
+
+func servicesBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countServices(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	return countBadge(badgeLabel("services", params), count, params), nil
+}
+
+// serviceBadge reports the ready endpoints of a single service from its
+// EndpointSlices, catching a selector that matches no ready pods while the
+// deployment behind it looks fine.
+func serviceBadge(namespace, name string) badgeFunc {
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		q, err := newListQuery(ctx, params)
+		if err != nil {
+			return badge{}, err
+		}
+		service, err := getService(ctx, q, namespace, name)
+		if apierrors.IsNotFound(err) {
+			return badge{}, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("service %s/%s not found", namespace, name))
+		}
+		recordClusterCall(q.Cluster, err)
+		if err != nil {
+			kubernetesErrorsTotal.WithLabelValues("services", q.Cluster).Inc()
+			return badge{}, err
+		}
+		if service.Spec.Type == corev1.ServiceTypeExternalName {
+			return badge{Label: name, Message: service.Spec.ExternalName, Color: BADGE_COLOR_HEALTHY}, nil
+		}
+		slices, err := listEndpointSlices(ctx, listQuery{
+			NoCache:       q.NoCache,
+			Cluster:       q.Cluster,
+			Namespaces:    []string{namespace},
+			Impersonate:   q.Impersonate,
+			LabelSelector: discoveryv1.LabelServiceName + "=" + name,
+		})
+		if err != nil {
+			return badge{}, err
+		}
+		ready, total := endpointCount(slices)
+		count := healthCount{Healthy: ready, Total: total}
+		b := badge{
+			Label:   name,
+			Message: fmt.Sprintf("%d/%d ready endpoints", ready, total),
+			Color:   count.color(params),
+			Count:   count,
+		}
+		if ready == 0 {
+			b.Color = BADGE_COLOR_FATAL
+		}
+		return b, nil
+	}
+}
+
+func handleService(ctx echo.Context) error {
+	namespace, name := ctx.Param("namespace"), ctx.Param("name")
+	return serveBadge(ctx, "services/"+namespace+"/"+name, ctx.QueryParams(), serviceBadge(namespace, name))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// draining fails /readyz once shutdown has begun, so the pod leaves its
+// Service endpoints before the listener closes.
+var draining atomic.Bool
+
+// webhooksInFlight tracks the webhook posts shutdown waits for.
+var webhooksInFlight sync.WaitGroup
+
+// stopInformers shuts the started informer factories down, waiting for their
+// goroutines; their stop channel is the context the process was started with.
+func stopInformers() {
+	if informerFactory == nil {
+		return
+	}
+	informerFactory.Shutdown()
+	for _, factory := range namespaceFactories {
+		factory.Shutdown()
+	}
+}
+
+// waitWebhooks waits for the webhook posts in flight until ctx is done.
+func waitWebhooks(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		webhooksInFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("shutdown timed out waiting for webhooks")
+	}
+}
+
+// shutdown drains the server: /readyz fails for APP_SHUTDOWN_DELAY while
+// requests are still served, then the listener stops accepting connections,
+// the debounced webhook transitions are sent and in-flight requests, webhook
+// posts and informers get the rest of APP_SHUTDOWN_TIMEOUT to finish.
+func shutdown(e *echo.Echo) {
+	draining.Store(true)
+	slog.Info("shutting down", "delay", conf.ShutdownDelay.String(), "timeout", conf.ShutdownTimeout.String())
+	time.Sleep(conf.ShutdownDelay)
+	ctx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		slog.Error("server shutdown failed", "error", err.Error())
+	}
+	flushTransitions()
+	waitWebhooks(ctx)
+	stopInformers()
+}
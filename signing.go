@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// credentialParams authenticate a request without changing the badge, so they
+// are left out of cache keys.
+var credentialParams = map[string]bool{"token": true, "sig": true, "expires": true}
+
+// signature is the hex HMAC-SHA256 of path and the query without ?sig=, whose
+// keys url.Values.Encode sorts.
+func signature(secret, path string, query url.Values) string {
+	unsigned := url.Values{}
+	for key, values := range query {
+		if key != "sig" {
+			unsigned[key] = values
+		}
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(path + "?" + unsigned.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkSignature verifies ?sig= and, when present, that ?expires= (unix
+// seconds, covered by the signature) has not passed.
+func checkSignature(ctx echo.Context, secret string) error {
+	return verifySignature(secret, ctx.Request().URL.Path, ctx.QueryParams())
+}
+
+// verifySignature checks query as if it was requested at path, which is how
+// the items of POST /batch are signed.
+func verifySignature(secret, path string, query url.Values) error {
+	sig := query.Get("sig")
+	if sig == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "signature required")
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, path, query))) {
+		return echo.NewHTTPError(http.StatusForbidden, "invalid signature")
+	}
+	if value := query.Get("expires"); value != "" {
+		expires, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusForbidden, "invalid signature")
+		}
+		if time.Now().Unix() > expires {
+			return echo.NewHTTPError(http.StatusForbidden, "signature expired")
+		}
+	}
+	return nil
+}
+
+// signatureMiddleware requires badge requests to carry a valid signature from
+// APP_SIGNING_SECRET unless they present one of APP_AUTH_TOKENS, so badges can
+// be embedded publicly without letting anyone query other namespaces or selectors.
+func signatureMiddleware(secret string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if validToken(requestToken(ctx), conf.AuthTokens) {
+				return next(ctx)
+			}
+			if err := checkSignature(ctx, secret); err != nil {
+				return respondError(ctx, err)
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// signURL appends ?sig= (and ?expires= when ttl is positive) to rawURL.
+func signURL(secret, rawURL string, ttl time.Duration) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Path == "" {
+		return "", errors.New("missing path")
+	}
+	query := u.Query()
+	query.Del("sig")
+	if ttl > 0 {
+		query.Set("expires", strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
+	}
+	query.Set("sig", signature(secret, u.Path, query))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// runSign implements "k8s-status-badge sign <path?query> [ttl]", printing the
+// signed URL for embedding.
+func runSign(args []string) error {
+	if conf.SigningSecret == "" {
+		return errors.New("APP_SIGNING_SECRET is not set")
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: k8s-status-badge sign <path?query> [ttl]")
+	}
+	var ttl time.Duration
+	if len(args) == 2 {
+		var err error
+		if ttl, err = time.ParseDuration(args[1]); err != nil {
+			return err
+		}
+	}
+	signed, err := signURL(conf.SigningSecret, args[0], ttl)
+	if err != nil {
+		return err
+	}
+	fmt.Println(signed)
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type lastKnownBadge struct {
+	badge badge
+	at    time.Time
+}
+
+var (
+	lastKnownMu sync.Mutex
+	// lastKnown is keyed by request parameters, so like renderedBodies it is
+	// emptied once it holds APP_STALE_CACHE_SIZE badges.
+	lastKnown = map[string]lastKnownBadge{}
+)
+
+func rememberBadge(key string, b badge) {
+	if conf.StaleCacheSize <= 0 {
+		return
+	}
+	lastKnownMu.Lock()
+	defer lastKnownMu.Unlock()
+	if _, ok := lastKnown[key]; !ok && len(lastKnown) >= conf.StaleCacheSize {
+		clear(lastKnown)
+	}
+	lastKnown[key] = lastKnownBadge{badge: b, at: time.Now()}
+}
+
+// staleBadge returns the last successful badge under key when err comes from
+// the Kubernetes API rather than the request, marked as stale and greyed out
+// once it is older than conf.StaleLimit.
+func staleBadge(key string, err error) (badge, bool) {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return badge{}, false
+	}
+	lastKnownMu.Lock()
+	known, ok := lastKnown[key]
+	lastKnownMu.Unlock()
+	if !ok {
+		return badge{}, false
+	}
+	b := known.badge
+	b.Stale = true
+	b.Message += " (stale)"
+	if time.Since(known.at) > conf.StaleLimit {
+		b.Color = BADGE_COLOR_ERROR
+	}
+	return b, true
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRememberBadgeBounded(t *testing.T) {
+	setupTest(t, map[string]string{"APP_STALE_CACHE_SIZE": "10"})
+	clear(lastKnown)
+	for i := range 25 {
+		rememberBadge(fmt.Sprintf("pods?label=%d", i), badge{Message: "1/1"})
+	}
+	if len(lastKnown) > 10 {
+		t.Errorf("lastKnown holds %d badges, want at most 10", len(lastKnown))
+	}
+	if b, ok := staleBadge("pods?label=24", errors.New("connection refused")); !ok || b.Message != "1/1 (stale)" {
+		t.Errorf("staleBadge = %q, %v, want the latest badge", b.Message, ok)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/url"
+)
+
+func init() {
+	registerEvaluator(countingEvaluator{
+		funcEvaluator: funcEvaluator{
+			name:    "statefulsets",
+			enabled: func() bool { return conf.EnableStatefulSets },
+			badge:   statefulSetsBadge,
+		},
+		count: countStatefulSets,
+	})
+}
+
+func countStatefulSets(ctx context.Context, params url.Values) (healthCount, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return healthCount{}, err
+	}
+	statefulSets, err := listStatefulSets(ctx, q)
+	if err != nil {
+		return healthCount{}, err
+	}
+	annotation := params.Get("annotation")
+	count := healthCount{}
+	for _, statefulSet := range statefulSets {
+		if !matchAnnotation(statefulSet, annotation) {
+			continue
+		}
+		desired := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			desired = *statefulSet.Spec.Replicas
+		}
+		count.add(statefulSet, statefulSet.Status.ReadyReplicas >= desired)
+	}
+	return count, nil
+}
+
+func statefulSetsBadge(ctx context.Context, params url.Values) (badge, error) {
+	count, err := countStatefulSets(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	return countBadge(badgeLabel("statefulsets", params), count, params), nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// STATS_MAX_BADGES bounds the tracked paths, since /badge/:name accepts any name.
+	STATS_MAX_BADGES = 1000
+	// STATS_MAX_REFERRERS bounds the referrers tracked per path.
+	STATS_MAX_REFERRERS = 100
+)
+
+// badgeUsage is how often a badge path was requested and from where.
+type badgeUsage struct {
+	Requests   int64
+	Referrers  map[string]int64
+	LastAccess time.Time
+}
+
+var (
+	badgeUsageMu sync.Mutex
+	usageByPath  = map[string]*badgeUsage{}
+)
+
+// referrer reduces a Referer header to scheme, host and path, so query strings
+// do not split or leak into the stats.
+func referrer(header string) string {
+	u, err := url.Parse(header)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+func recordUsage(path, ref string, now time.Time) {
+	badgeUsageMu.Lock()
+	defer badgeUsageMu.Unlock()
+	usage, ok := usageByPath[path]
+	if !ok {
+		if len(usageByPath) >= STATS_MAX_BADGES {
+			return
+		}
+		usage = &badgeUsage{Referrers: map[string]int64{}}
+		usageByPath[path] = usage
+	}
+	usage.Requests++
+	usage.LastAccess = now
+	if _, seen := usage.Referrers[ref]; ref != "" && (seen || len(usage.Referrers) < STATS_MAX_REFERRERS) {
+		usage.Referrers[ref]++
+	}
+}
+
+// statsMiddleware records each badge request under its path.
+func statsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		recordUsage(ctx.Request().URL.Path, referrer(ctx.Request().Referer()), time.Now())
+		return next(ctx)
+	}
+}
+
+// handleStats lists the usage of every requested badge path since startup,
+// e.g. to find badges no README embeds any more.
+func handleStats(ctx echo.Context) error {
+	badgeUsageMu.Lock()
+	defer badgeUsageMu.Unlock()
+	paths := make([]string, 0, len(usageByPath))
+	for path := range usageByPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	result := make([]echo.Map, 0, len(paths))
+	for _, path := range paths {
+		usage := usageByPath[path]
+		result = append(result, echo.Map{
+			"path":       path,
+			"requests":   usage.Requests,
+			"referrers":  usage.Referrers,
+			"lastAccess": usage.LastAccess,
+		})
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
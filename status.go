@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed status.html
+var statusHTML string
+
+var statusTemplate = template.Must(template.New("status").Parse(statusHTML))
+
+var (
+	evaluatedMu sync.Mutex
+	evaluatedAt = map[string]time.Time{}
+)
+
+// markEvaluated records when the badge cached under name was last computed.
+func markEvaluated(name string) {
+	evaluatedMu.Lock()
+	defer evaluatedMu.Unlock()
+	evaluatedAt[name] = time.Now()
+}
+
+// anyEvaluated reports whether some badge has been computed since startup.
+func anyEvaluated() bool {
+	evaluatedMu.Lock()
+	defer evaluatedMu.Unlock()
+	return len(evaluatedAt) > 0
+}
+
+func lastEvaluated(name string) time.Time {
+	evaluatedMu.Lock()
+	defer evaluatedMu.Unlock()
+	return evaluatedAt[name]
+}
+
+type statusRow struct {
+	Name        string
+	Path        string
+	SVG         template.HTML
+	Error       string
+	EvaluatedAt time.Time
+}
+
+// handleStatus renders every enabled resource badge and every named badge on
+// one HTML page, refreshing every ?refresh= seconds (APP_STATUS_REFRESH by default).
+func handleStatus(ctx echo.Context) error {
+	refresh := int(conf.StatusRefresh.Seconds())
+	if value := ctx.QueryParam("refresh"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return ctx.JSON(http.StatusBadRequest, fmt.Sprintf("invalid refresh: %s", value))
+		}
+		refresh = parsed
+	}
+	var rows []statusRow
+	for _, l := range listedBadges() {
+		row := statusRow{Name: l.name, Path: "/" + l.name}
+		b, err := computeBadge(ctx.Request().Context(), l.name, l.params, l.compute)
+		if err != nil {
+			row.Error = errorMessage(err)
+		} else {
+			row.SVG = template.HTML(renderSVG(b))
+		}
+		row.EvaluatedAt = lastEvaluated(l.name)
+		rows = append(rows, row)
+	}
+
+	title := "Cluster status"
+	if conf.Env != "" {
+		title += " (" + conf.Env + ")"
+	}
+	var body bytes.Buffer
+	err := statusTemplate.Execute(&body, map[string]any{
+		"Title":      title,
+		"Refresh":    refresh,
+		"Rows":       rows,
+		"RenderedAt": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.HTMLBlob(http.StatusOK, body.Bytes())
+}
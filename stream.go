@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"k8s.io/client-go/tools/cache"
+)
+
+// badgeEvent is pushed to /events/stream subscribers when a badge changes.
+type badgeEvent struct {
+	Badge   string `json:"badge"`
+	Label   string `json:"label"`
+	Message string `json:"message"`
+	Color   string `json:"color"`
+	Healthy int    `json:"healthy"`
+	Total   int    `json:"total"`
+}
+
+// badgeChanges is signalled by informer event handlers; its buffer of one
+// coalesces bursts into a single re-evaluation.
+var badgeChanges = make(chan struct{}, 1)
+
+var (
+	subscribersMu sync.Mutex
+	subscribers   = map[chan badgeEvent]bool{}
+	badgeStates   = map[string]badgeEvent{}
+)
+
+func notifyOnChange(informer cache.SharedIndexInformer, kind string) {
+	informerStores[kind] = append(informerStores[kind], informer.GetStore())
+	signal := func(old, obj any) {
+		select {
+		case badgeChanges <- struct{}{}:
+		default:
+		}
+		markChanged(kind, old, obj)
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { signal(nil, obj) },
+		UpdateFunc: func(old, obj any) { signal(old, obj) },
+		DeleteFunc: func(obj any) { signal(nil, obj) },
+	})
+}
+
+// badgeChangesWanted reports whether anyone consumes the re-evaluations: an
+// /events/stream subscriber of this replica or, on the leader, the webhooks
+// and the BadgeStatus objects.
+func badgeChangesWanted() bool {
+	subscribersMu.Lock()
+	watched := len(subscribers) > 0
+	subscribersMu.Unlock()
+	return watched || leading.Load() && (len(configuredWebhooks()) > 0 || conf.StatusNamespace != "")
+}
+
+// watchBadgeChanges re-evaluates the listed badges after informer events, or
+// every APP_EVENTS_INTERVAL when informers are disabled or webhooks have to see
+// debounced transitions through, and publishes the ones whose color or message
+// changed. Without anyone consuming them it only waits for the next subscriber.
+func watchBadgeChanges(ctx context.Context) {
+	var tick <-chan time.Time
+	if informerFactory == nil || len(configuredWebhooks()) > 0 {
+		ticker := time.NewTicker(conf.EventsInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	evaluateBadgeChanges(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-badgeChanges:
+			// Let the rest of a burst of events land before evaluating.
+			time.Sleep(time.Second)
+		case <-tick:
+		}
+		evaluateBadgeChanges(ctx)
+	}
+}
+
+func evaluateBadgeChanges(ctx context.Context) {
+	if !badgeChangesWanted() {
+		return
+	}
+	for _, l := range listedBadges() {
+		params := url.Values{}
+		for key, values := range l.params {
+			params[key] = values
+		}
+		params.Set("nocache", "true")
+		b, err := computeBadge(ctx, l.name, params, l.compute)
+		if err != nil {
+			slog.Debug("badge evaluation failed", "badge", l.name, "error", err.Error())
+			continue
+		}
+		notifyTransition(l.name, b)
+		if conf.StatusNamespace != "" {
+			writeBadgeStatus(ctx, l.name, b)
+		}
+		publishBadge(badgeEvent{
+			Badge:   l.name,
+			Label:   b.Label,
+			Message: b.Message,
+			Color:   b.Color,
+			Healthy: b.Count.Healthy,
+			Total:   b.Count.Total,
+		})
+	}
+}
+
+func publishBadge(event badgeEvent) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	previous, seen := badgeStates[event.Badge]
+	badgeStates[event.Badge] = event
+	if seen && previous.Color == event.Color && previous.Message == event.Message {
+		return
+	}
+	for subscriber := range subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			// Drop events for subscribers that are not keeping up.
+		}
+	}
+}
+
+// subscribe returns a channel of badge events primed with the current state.
+// The first subscriber triggers an evaluation, as none ran without one.
+func subscribe() chan badgeEvent {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	if len(subscribers) == 0 {
+		select {
+		case badgeChanges <- struct{}{}:
+		default:
+		}
+	}
+	events := make(chan badgeEvent, 64+len(badgeStates))
+	for _, state := range badgeStates {
+		events <- state
+	}
+	subscribers[events] = true
+	return events
+}
+
+func unsubscribe(events chan badgeEvent) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	delete(subscribers, events)
+}
+
+func handleEventStream(ctx echo.Context) error {
+	events := subscribe()
+	defer unsubscribe(events)
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+	done := ctx.Request().Context().Done()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-keepAlive.C:
+			fmt.Fprint(res, ": keep-alive\n\n")
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(res, "event: badge\ndata: %s\n\n", data)
+		}
+		res.Flush()
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// verdanaWidths approximates the advance width in pixels of printable ASCII
+// characters in 11px Verdana, the font shields.io badges are laid out with.
+var verdanaWidths = map[rune]float64{
+	' ': 3.87, '!': 4.33, '"': 5.05, '#': 9.0, '$': 6.99, '%': 11.84, '&': 7.99, '\'': 2.95,
+	'(': 4.99, ')': 4.99, '*': 6.99, '+': 9.0, ',': 4.0, '-': 4.99, '.': 4.0, '/': 4.99,
+	'0': 6.99, '1': 6.99, '2': 6.99, '3': 6.99, '4': 6.99, '5': 6.99, '6': 6.99, '7': 6.99,
+	'8': 6.99, '9': 6.99, ':': 4.99, ';': 4.99, '<': 9.0, '=': 9.0, '>': 9.0, '?': 5.99,
+	'@': 11.0, 'A': 7.52, 'B': 7.54, 'C': 7.68, 'D': 8.48, 'E': 6.96, 'F': 6.32, 'G': 8.53,
+	'H': 8.27, 'I': 4.62, 'J': 5.0, 'K': 7.62, 'L': 6.12, 'M': 9.27, 'N': 8.23, 'O': 8.66,
+	'P': 6.63, 'Q': 8.66, 'R': 7.65, 'S': 7.52, 'T': 6.78, 'U': 8.05, 'V': 7.52, 'W': 10.88,
+	'X': 7.54, 'Y': 6.77, 'Z': 7.54, '[': 4.99, '\\': 4.99, ']': 4.99, '^': 9.0, '_': 6.99,
+	'`': 6.99, 'a': 6.61, 'b': 6.85, 'c': 5.73, 'd': 6.85, 'e': 6.55, 'f': 3.87, 'g': 6.85,
+	'h': 6.96, 'i': 3.02, 'j': 3.79, 'k': 6.51, 'l': 3.02, 'm': 10.7, 'n': 6.96, 'o': 6.68,
+	'p': 6.85, 'q': 6.85, 'r': 4.69, 's': 5.73, 't': 4.33, 'u': 6.96, 'v': 6.51, 'w': 9.0,
+	'x': 6.51, 'y': 6.51, 'z': 5.77, '{': 6.98, '|': 4.99, '}': 6.98, '~': 9.0,
+}
+
+func textWidth(text string) float64 {
+	width := 0.0
+	for _, r := range text {
+		if w, ok := verdanaWidths[r]; ok {
+			width += w
+		} else {
+			width += 7
+		}
+	}
+	return width
+}
+
+var namedColors = map[string]string{
+	"brightgreen":   "#4c1",
+	"green":         "#97ca00",
+	"yellowgreen":   "#a4a61d",
+	"yellow":        "#dfb317",
+	"orange":        "#fe7d37",
+	"red":           "#e05d44",
+	"blue":          "#007ec6",
+	"grey":          "#555",
+	"gray":          "#555",
+	"lightgrey":     "#9f9f9f",
+	"lightgray":     "#9f9f9f",
+	"success":       "#4c1",
+	"important":     "#fe7d37",
+	"critical":      "#e05d44",
+	"informational": "#007ec6",
+	"inactive":      "#9f9f9f",
+}
+
+var hexColorPattern = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// svgColor resolves shields color names and bare hex codes to an SVG fill.
+func svgColor(color string) string {
+	if named, ok := namedColors[strings.ToLower(color)]; ok {
+		return named
+	}
+	if hexColorPattern.MatchString(color) {
+		return "#" + strings.TrimPrefix(color, "#")
+	}
+	return namedColors["lightgrey"]
+}
+
+// svgLogoWidth is the width of a logo and the gap after it, as shields lays
+// out a logo in front of the label.
+const svgLogoWidth = 14
+
+// renderSVG lays out b like a shields.io badge in the "flat" (default) or
+// "flat-square" style, with the custom logo in front of the label and wrapped
+// in a link when b has them.
+func renderSVG(b badge) []byte {
+	logoWidth := 0.0
+	if b.LogoSVG != "" {
+		logoWidth = svgLogoWidth + 3
+	}
+	labelWidth := math.Ceil(textWidth(b.Label)) + 10 + logoWidth
+	messageWidth := math.Ceil(textWidth(b.Message)) + 10
+	width := labelWidth + messageWidth
+	label := html.EscapeString(b.Label)
+	message := html.EscapeString(b.Message)
+	title := html.EscapeString(b.Label + ": " + b.Message)
+	color := svgColor(b.Color)
+	labelColor := "#555"
+	if b.LabelColor != "" {
+		labelColor = svgColor(b.LabelColor)
+	}
+	labelX := logoWidth + (labelWidth-logoWidth)/2
+	messageX := labelWidth + messageWidth/2
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%g" height="20" role="img" aria-label="%s">`, width, title)
+	fmt.Fprintf(&svg, `<title>%s</title>`, title)
+	if b.Link != "" {
+		fmt.Fprintf(&svg, `<a target="_blank" xlink:href="%s">`, html.EscapeString(b.Link))
+	}
+	if b.Style == "flat-square" {
+		fmt.Fprintf(&svg, `<g shape-rendering="crispEdges"><rect width="%g" height="20" fill="%s"/><rect x="%g" width="%g" height="20" fill="%s"/></g>`, labelWidth, labelColor, labelWidth, messageWidth, color)
+		svg.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+		fmt.Fprintf(&svg, `<text x="%g" y="14">%s</text><text x="%g" y="14">%s</text></g>`, labelX, label, messageX, message)
+	} else {
+		svg.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+		fmt.Fprintf(&svg, `<clipPath id="r"><rect width="%g" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+		fmt.Fprintf(&svg, `<g clip-path="url(#r)"><rect width="%g" height="20" fill="%s"/><rect x="%g" width="%g" height="20" fill="%s"/><rect width="%g" height="20" fill="url(#s)"/></g>`, labelWidth, labelColor, labelWidth, messageWidth, color, width)
+		svg.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+		fmt.Fprintf(&svg, `<text x="%g" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%g" y="14">%s</text>`, labelX, label, labelX, label)
+		fmt.Fprintf(&svg, `<text x="%g" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%g" y="14">%s</text></g>`, messageX, message, messageX, message)
+	}
+	if b.LogoSVG != "" {
+		fmt.Fprintf(&svg, `<image x="5" y="3" width="%d" height="14" xlink:href="data:image/svg+xml;base64,%s"/>`, svgLogoWidth, base64.StdEncoding.EncodeToString([]byte(b.LogoSVG)))
+	}
+	if b.Link != "" {
+		svg.WriteString(`</a>`)
+	}
+	svg.WriteString(`</svg>`)
+	return []byte(svg.String())
+}
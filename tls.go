@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// tlsReloader serves the certificate, key and optional client CA read from
+// disk, reloading them on SIGHUP or when one of the files changes so
+// cert-manager rotations apply without a restart.
+type tlsReloader struct {
+	certFile, keyFile, clientCAFile string
+	config                          atomic.Pointer[tls.Config]
+}
+
+func newTLSReloader(certFile, keyFile, clientCAFile string) (*tlsReloader, error) {
+	r := &tlsReloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *tlsReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.clientCAFile != "" {
+		data, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("%s: no certificates found", r.clientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	r.config.Store(config)
+	return nil
+}
+
+// serverConfig returns the listener configuration, which picks up the latest
+// reloaded certificates for every handshake.
+func (r *tlsReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.config.Load(), nil
+		},
+	}
+}
+
+func (r *tlsReloader) modTimes() []time.Time {
+	var times []time.Time
+	for _, path := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if path == "" {
+			continue
+		}
+		var modTime time.Time
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime()
+		}
+		times = append(times, modTime)
+	}
+	return times
+}
+
+func (r *tlsReloader) watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastMod := r.modTimes()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-ticker.C:
+			modTimes := r.modTimes()
+			if slices.Equal(modTimes, lastMod) {
+				continue
+			}
+		}
+		lastMod = r.modTimes()
+		if err := r.reload(); err != nil {
+			slog.Error("tls reload failed", "cert", r.certFile, "error", err.Error())
+			continue
+		}
+		slog.Info("tls certificates reloaded", "cert", r.certFile)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func init() {
+	registerEvaluator(funcEvaluator{
+		name:    "topology",
+		enabled: func() bool { return conf.EnableTopology },
+		badge:   topologyBadge,
+	})
+}
+
+// topologySpread is how the scheduled pods of a deployment are spread over the
+// domains of a topology key.
+type topologySpread struct {
+	pods    int
+	domains int
+	skew    int
+	// maxSkew comes from the deployment's spread constraint on the key; 0
+	// without one.
+	maxSkew int32
+}
+
+// singleDomain reports whether several replicas share one domain although
+// the cluster has more.
+func (s topologySpread) singleDomain(clusterDomains int) bool {
+	return s.pods > 1 && s.domains == 1 && clusterDomains > 1
+}
+
+func (s topologySpread) skewed() bool {
+	return s.maxSkew > 0 && int32(s.skew) > s.maxSkew
+}
+
+func maxSkew(deployment *appsv1.Deployment, key string) int32 {
+	for _, constraint := range deployment.Spec.Template.Spec.TopologySpreadConstraints {
+		if constraint.TopologyKey == key {
+			return constraint.MaxSkew
+		}
+	}
+	return 0
+}
+
+// spreadOf places the running and pending pods of deployment in the domains
+// of nodeDomains. The skew is taken over every domain of the cluster, like the
+// scheduler does for nodes it considers eligible.
+func spreadOf(deployment *appsv1.Deployment, pods []*corev1.Pod, nodeDomains map[string]string, clusterDomains map[string]bool, key string) (topologySpread, error) {
+	selector, err := v1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return topologySpread{}, err
+	}
+	counts := map[string]int{}
+	spread := topologySpread{maxSkew: maxSkew(deployment, key)}
+	for _, pod := range pods {
+		if pod.Namespace != deployment.Namespace || pod.DeletionTimestamp != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		domain, ok := nodeDomains[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		counts[domain]++
+		spread.pods++
+	}
+	spread.domains = len(counts)
+	if spread.pods == 0 {
+		return spread, nil
+	}
+	lowest, highest := spread.pods, 0
+	for domain := range clusterDomains {
+		lowest = min(lowest, counts[domain])
+		highest = max(highest, counts[domain])
+	}
+	spread.skew = highest - lowest
+	return spread, nil
+}
+
+// topologyBadge reports how the selected deployments spread their pods over
+// the domains of ?topologyKey=, zones by default; kubernetes.io/hostname or
+// kubernetes.io/arch check nodes or architectures instead. It is red while all
+// replicas of a deployment share one domain and yellow when a deployment
+// exceeds the maxSkew of its topology spread constraint.
+func topologyBadge(ctx context.Context, params url.Values) (badge, error) {
+	key := cmp.Or(params.Get("topologyKey"), corev1.LabelTopologyZone)
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	deployments, err := listDeployments(ctx, q)
+	if err != nil {
+		return badge{}, err
+	}
+	pods, err := listPods(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Namespaces: q.Namespaces, Impersonate: q.Impersonate})
+	if err != nil {
+		return badge{}, err
+	}
+	nodes, err := listNodes(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate})
+	if err != nil {
+		return badge{}, err
+	}
+	nodeDomains := map[string]string{}
+	clusterDomains := map[string]bool{}
+	for _, node := range nodes {
+		if domain, ok := node.Labels[key]; ok {
+			nodeDomains[node.Name] = domain
+			clusterDomains[domain] = true
+		}
+	}
+
+	annotation := params.Get("annotation")
+	count := healthCount{}
+	single, skewed := 0, 0
+	for _, deployment := range deployments {
+		if !matchAnnotation(deployment, annotation) {
+			continue
+		}
+		spread, err := spreadOf(deployment, pods, nodeDomains, clusterDomains, key)
+		if err != nil {
+			continue
+		}
+		switch {
+		case spread.singleDomain(len(clusterDomains)):
+			single++
+			count.add(deployment, false)
+		case spread.skewed():
+			skewed++
+			count.add(deployment, false)
+		default:
+			count.add(deployment, true)
+		}
+	}
+
+	b := countBadge(badgeLabel("topology", params), count, params)
+	b.Message += " spread"
+	if single > 0 {
+		b.Message += fmt.Sprintf(", %d in one %s", single, path.Base(key))
+	}
+	if skewed > 0 {
+		b.Message += fmt.Sprintf(", %d skewed", skewed)
+	}
+	switch {
+	case single > 0 || count.Critical > 0:
+		b.Color = BADGE_COLOR_FATAL
+	case skewed > 0:
+		b.Color = BADGE_COLOR_WARN
+	default:
+		b.Color = BADGE_COLOR_HEALTHY
+	}
+	return b, nil
+}
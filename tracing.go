@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"k8s.io/client-go/rest"
+)
+
+const TRACING_SERVICE_NAME = "k8s-status-badge"
+
+var tracer = otel.Tracer(TRACING_SERVICE_NAME)
+
+// setupTracing exports spans to the OTLP/HTTP endpoint (e.g.
+// "http://otel-collector:4318") and returns a function flushing them on shutdown.
+func setupTracing(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(sdkresource.NewSchemaless(semconv.ServiceName(TRACING_SERVICE_NAME))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// traceClient makes client-go record a span for each Kubernetes API request
+// when tracing is enabled.
+func traceClient(config *rest.Config) {
+	if conf.OTLPEndpoint == "" {
+		return
+	}
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return otelhttp.NewTransport(rt)
+	})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	registerEvaluator(funcEvaluator{
+		name:    "usage/nodes",
+		enabled: func() bool { return conf.EnableUsage },
+		badge:   usageBadge("node usage", nodeUsage),
+	})
+	registerEvaluator(funcEvaluator{
+		name:    "usage/pods",
+		enabled: func() bool { return conf.EnableUsage },
+		badge:   usageBadge("pod usage", podUsage),
+	})
+}
+
+var (
+	nodeMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "nodes"}
+	podMetricsGVR  = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+)
+
+var usageResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// addUsage adds the cpu and memory quantities in usage (a metrics.k8s.io usage map) to totals.
+func addUsage(totals map[corev1.ResourceName]int64, usage map[string]any) {
+	for _, name := range usageResources {
+		value, ok := usage[string(name)].(string)
+		if !ok {
+			continue
+		}
+		if quantity, err := apiresource.ParseQuantity(value); err == nil {
+			totals[name] += quantity.MilliValue()
+		}
+	}
+}
+
+func nodeUsage(ctx context.Context, q listQuery) (map[corev1.ResourceName]int64, error) {
+	metrics, err := listCustom(ctx, nodeMetricsGVR, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate, LabelSelector: q.LabelSelector})
+	if err != nil {
+		return nil, err
+	}
+	totals := map[corev1.ResourceName]int64{}
+	for _, metric := range metrics {
+		usage, _, _ := unstructured.NestedMap(metric.Object, "usage")
+		addUsage(totals, usage)
+	}
+	return totals, nil
+}
+
+func podUsage(ctx context.Context, q listQuery) (map[corev1.ResourceName]int64, error) {
+	metrics, err := listCustom(ctx, podMetricsGVR, q)
+	if err != nil {
+		return nil, err
+	}
+	totals := map[corev1.ResourceName]int64{}
+	for _, metric := range metrics {
+		containers, _, _ := unstructured.NestedSlice(metric.Object, "containers")
+		for _, container := range containers {
+			fields, ok := container.(map[string]any)
+			if !ok {
+				continue
+			}
+			usage, _ := fields["usage"].(map[string]any)
+			addUsage(totals, usage)
+		}
+	}
+	return totals, nil
+}
+
+func allocatable(ctx context.Context, q listQuery) (map[corev1.ResourceName]int64, error) {
+	nodes, err := listNodes(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate})
+	if err != nil {
+		return nil, err
+	}
+	totals := map[corev1.ResourceName]int64{}
+	for _, node := range nodes {
+		for _, name := range usageResources {
+			quantity := node.Status.Allocatable[name]
+			totals[name] += quantity.MilliValue()
+		}
+	}
+	return totals, nil
+}
+
+// usageColor colors by the highest utilization; unlike health ratios, higher
+// is worse, so it uses APP_USAGE_WARN_THRESHOLD and APP_USAGE_FATAL_THRESHOLD.
+func usageColor(utilization float64) string {
+	switch {
+	case utilization >= conf.UsageFatalThreshold:
+		return BADGE_COLOR_FATAL
+	case utilization >= conf.UsageWarnThreshold:
+		return BADGE_COLOR_WARN
+	default:
+		return BADGE_COLOR_HEALTHY
+	}
+}
+
+// usageBadge reports the used share of node allocatable for cpu and memory,
+// or only ?resource=cpu|memory.
+func usageBadge(kind string, load func(context.Context, listQuery) (map[corev1.ResourceName]int64, error)) badgeFunc {
+	return func(ctx context.Context, params url.Values) (badge, error) {
+		names := usageResources
+		if value := params.Get("resource"); value != "" {
+			name := corev1.ResourceName(value)
+			if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+				return badge{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown resource: %s", value))
+			}
+			names = []corev1.ResourceName{name}
+		}
+		q, err := newListQuery(ctx, params)
+		if err != nil {
+			return badge{}, err
+		}
+		used, err := load(ctx, q)
+		if err != nil {
+			return badge{}, err
+		}
+		capacity, err := allocatable(ctx, q)
+		if err != nil {
+			return badge{}, err
+		}
+		var parts []string
+		highest := 0.0
+		for _, name := range names {
+			utilization := 0.0
+			if capacity[name] > 0 {
+				utilization = float64(used[name]) / float64(capacity[name])
+			}
+			highest = max(highest, utilization)
+			parts = append(parts, fmt.Sprintf("%s %.0f%%", name, utilization*100))
+		}
+		return badge{
+			Label:   badgeLabel(kind, params),
+			Message: strings.Join(parts, ", "),
+			Color:   usageColor(highest),
+		}, nil
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func init() {
+	registerEvaluator(funcEvaluator{
+		name:    "version",
+		enabled: func() bool { return conf.EnableVersion },
+		badge:   versionBadge,
+	})
+}
+
+func serverVersion(ctx context.Context, q listQuery) (string, error) {
+	info, err := cached(ctx, listCache, q.cacheKey("version", ""), q.NoCache, func() (string, error) {
+		// Discovery's ServerVersion takes no context, so fetch /version directly
+		// to bound it by APP_K8S_TIMEOUT.
+		ctx, cancel := withK8sTimeout(ctx)
+		defer cancel()
+		body, err := q.client().Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Raw()
+		if err != nil {
+			return "", err
+		}
+		var info version.Info
+		if err := json.Unmarshal(body, &info); err != nil {
+			return "", fmt.Errorf("unable to parse the server version: %w", err)
+		}
+		return info.GitVersion, nil
+	})
+	recordClusterCall(q.Cluster, err)
+	if err != nil {
+		kubernetesErrorsTotal.WithLabelValues("version", q.Cluster).Inc()
+	}
+	return info, err
+}
+
+// versionBadge reports the API server version and the range of kubelet
+// versions. It turns yellow when kubelets lag more than one minor version and
+// red beyond APP_MAX_KUBELET_SKEW or when a kubelet is newer than the API server.
+func versionBadge(ctx context.Context, params url.Values) (badge, error) {
+	q, err := newListQuery(ctx, params)
+	if err != nil {
+		return badge{}, err
+	}
+	gitVersion, err := serverVersion(ctx, q)
+	if err != nil {
+		return badge{}, err
+	}
+	nodes, err := listNodes(ctx, listQuery{NoCache: q.NoCache, Cluster: q.Cluster, Impersonate: q.Impersonate, LabelSelector: q.LabelSelector})
+	if err != nil {
+		return badge{}, err
+	}
+	b := badge{Label: badgeLabel("version", params), Message: gitVersion, Color: BADGE_COLOR_HEALTHY}
+	server, err := utilversion.ParseGeneric(gitVersion)
+	if err != nil {
+		return b, nil
+	}
+	var oldest, newest *utilversion.Version
+	for _, node := range nodes {
+		kubelet, err := utilversion.ParseGeneric(node.Status.NodeInfo.KubeletVersion)
+		if err != nil {
+			continue
+		}
+		if oldest == nil || kubelet.LessThan(oldest) {
+			oldest = kubelet
+		}
+		if newest == nil || newest.LessThan(kubelet) {
+			newest = kubelet
+		}
+	}
+	if oldest == nil {
+		return b, nil
+	}
+	if oldest.EqualTo(newest) {
+		b.Message += fmt.Sprintf(", kubelets v%s", oldest)
+	} else {
+		b.Message += fmt.Sprintf(", kubelets v%s-v%s", oldest, newest)
+	}
+	skew := int(server.Minor()) - int(oldest.Minor())
+	switch {
+	case server.Major() != oldest.Major() || server.Major() != newest.Major() || newest.Minor() > server.Minor() || skew > conf.MaxKubeletSkew:
+		b.Color = BADGE_COLOR_FATAL
+	case skew > 1:
+		b.Color = BADGE_COLOR_WARN
+	}
+	return b, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// warmingUp holds /readyz back until warmUp has primed the badge cache.
+var warmingUp atomic.Bool
+
+// warmUp evaluates every listed badge once so the first requests are served
+// from the cache, giving up after timeout. The server is ready afterwards
+// either way; badges that failed are simply computed on request.
+func warmUp(ctx context.Context, timeout time.Duration) {
+	defer warmingUp.Store(false)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	listed := listedBadges()
+	failed := 0
+	for i, l := range listed {
+		if _, err := computeBadge(ctx, l.name, l.params, l.compute); err != nil {
+			failed++
+			slog.Warn("warm-up badge failed", "badge", l.name, "error", errorMessage(err))
+		}
+		if ctx.Err() != nil {
+			slog.Warn("warm-up timed out", "done", i+1, "total", len(listed), "timeout", timeout.String())
+			return
+		}
+		slog.Info("warm-up progress", "badge", l.name, "done", i+1, "total", len(listed))
+	}
+	slog.Info("warm-up finished", "badges", len(listed), "failed", failed, "duration_ms", time.Since(start).Milliseconds())
+}